@@ -0,0 +1,62 @@
+// cleanup.go
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/yoshi70001/googleDocsOCR/gdrive"
+)
+
+// defaultSweepAge es la antigüedad a partir de la cual un documento de la
+// carpeta temporal se considera huérfano.
+const defaultSweepAge = time.Hour
+
+// runCleanup implementa el subcomando "cleanup": borra (o manda a la papelera)
+// los documentos que quedaron en la carpeta temporal de Drive.
+func runCleanup(args []string) {
+	fs := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	olderThan := fs.Duration("older-than", defaultSweepAge, "Antigüedad mínima de los documentos a limpiar")
+	dryRun := fs.Bool("dry-run", false, "Solo listar los documentos, sin borrarlos")
+	trash := fs.Bool("trash", false, "Mover a la papelera en lugar de borrar definitivamente")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	srv, err := gdrive.AuthenticateAndGetService()
+	if err != nil {
+		log.Fatalf("Fallo en la autenticación: %v", err)
+	}
+	log.Println("✓ Autenticación exitosa.")
+
+	// Sin carpeta no hay nada que limpiar, y crearla no tendría sentido
+	// (menos aún en un dry-run).
+	driveFolderID, err := gdrive.FindFolder(srv, driveTempFolder)
+	if err != nil {
+		log.Fatalf("No se pudo buscar la carpeta de Drive: %v", err)
+	}
+	if driveFolderID == "" {
+		log.Printf("✓ La carpeta '%s' no existe en Drive: no hay nada que limpiar.", driveTempFolder)
+		return
+	}
+
+	log.Printf("Buscando documentos con más de %s en '%s'...", *olderThan, driveTempFolder)
+	orphans, err := gdrive.SweepFolder(ctx, srv, driveFolderID, gdrive.SweepOptions{
+		OlderThan: *olderThan,
+		DryRun:    *dryRun,
+		Trash:     *trash,
+	})
+	if err != nil {
+		log.Fatalf("Fallo al limpiar la carpeta temporal: %v", err)
+	}
+
+	switch {
+	case len(orphans) == 0:
+		log.Println("✓ No hay documentos huérfanos.")
+	case *dryRun:
+		log.Printf("✓ %d documentos huérfanos encontrados (dry-run, no se modificó nada).", len(orphans))
+	default:
+		log.Printf("✓ %d documentos huérfanos limpiados.", len(orphans))
+	}
+}
@@ -0,0 +1,106 @@
+// dedup.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yoshi70001/googleDocsOCR/gdrive"
+	"github.com/yoshi70001/googleDocsOCR/manifest"
+	"github.com/yoshi70001/googleDocsOCR/phash"
+)
+
+// driveCallsPerImage son las llamadas a Drive de cada OCR: crear, exportar y
+// borrar el documento.
+const driveCallsPerImage = 3
+
+// dedupCluster es una imagen que se reconoce y las imágenes consecutivas casi
+// idénticas que copiarán su texto.
+type dedupCluster struct {
+	rep        *imageJob
+	duplicates []*imageJob
+}
+
+// dedupJobs agrupa las imágenes pendientes consecutivas cuyo hash perceptual
+// está a una distancia de la primera del grupo menor o igual que threshold.
+// Devuelve las imágenes que hay que reconocer y los grupos con duplicados.
+func dedupJobs(jobs []*imageJob, threshold float64) ([]*imageJob, []dedupCluster) {
+	var reps []*imageJob
+	var clusters []dedupCluster
+	var current *dedupCluster
+	var repHash phash.Hash
+
+	for _, job := range jobs {
+		hash, err := phash.File(job.imagePath)
+		if err != nil {
+			// Sin hash no se puede comparar: se reconoce por su cuenta.
+			log.Printf("[!] ADVERTENCIA: No se pudo calcular el hash de '%s': %v", job.filename, err)
+			reps = append(reps, job)
+			current = nil
+			continue
+		}
+		if current != nil && phash.Distance(repHash, hash) <= threshold {
+			current.duplicates = append(current.duplicates, job)
+			continue
+		}
+		reps = append(reps, job)
+		clusters = append(clusters, dedupCluster{rep: job})
+		current = &clusters[len(clusters)-1]
+		repHash = hash
+	}
+
+	// Solo interesan los grupos que tienen duplicados.
+	withDuplicates := clusters[:0]
+	for _, c := range clusters {
+		if len(c.duplicates) > 0 {
+			withDuplicates = append(withDuplicates, c)
+		}
+	}
+	return reps, withDuplicates
+}
+
+// copyDuplicates copia el texto de cada imagen reconocida a sus duplicados y
+// lo registra en el manifiesto. Si la representativa falló, sus duplicados se
+// marcan como fallidos en failed. Devuelve el número de textos copiados.
+func copyDuplicates(m *manifest.Manifest, clusters []dedupCluster, failed map[string]error) int {
+	copied := 0
+	for _, c := range clusters {
+		text, err := os.ReadFile(c.rep.textPath)
+		if repErr, ok := failed[c.rep.filename]; ok {
+			err = repErr
+		}
+		if err != nil {
+			err = fmt.Errorf("no se reconoció la imagen de la que es duplicado, '%s': %v", c.rep.filename, err)
+		}
+
+		for _, dup := range c.duplicates {
+			dupErr := err
+			if dupErr == nil {
+				if writeErr := os.WriteFile(dup.textPath, text, 0644); writeErr != nil {
+					dupErr = fmt.Errorf("no se pudo guardar el archivo de texto: %w", writeErr)
+				}
+			}
+			if dupErr != nil {
+				dup.record(m, manifest.StatusFailed, nil, dupErr)
+				failed[dup.filename] = dupErr
+				continue
+			}
+			dup.record(m, manifest.StatusDone, map[string]string{"dedup_of": c.rep.filename}, nil)
+			copied++
+		}
+	}
+	return copied
+}
+
+// logDedupSavings muestra cuántas peticiones de OCR se ahorraron.
+func logDedupSavings(copied int, engineName string) {
+	if copied == 0 {
+		return
+	}
+	if engineName == gdrive.EngineName {
+		log.Printf("✓ %d imágenes repetidas copiaron el texto de la anterior: %d llamadas a Drive menos.", copied, copied*driveCallsPerImage)
+		return
+	}
+	log.Printf("✓ %d imágenes repetidas copiaron el texto de la anterior: %d peticiones de OCR menos.", copied, copied)
+}
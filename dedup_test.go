@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yoshi70001/googleDocsOCR/manifest"
+)
+
+// fixtureJob copia la tira de subtítulo testdata/<fixture> a dir con el
+// nombre dado y devuelve su trabajo de OCR. frase_a.png y frase_b.png son
+// dos frases distintas.
+func fixtureJob(t *testing.T, dir, fixture, name string) *imageJob {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &imageJob{filename: name, imagePath: path, textPath: filepath.Join(dir, name+".txt")}
+}
+
+func TestDedupJobs(t *testing.T) {
+	dir := t.TempDir()
+	a1 := fixtureJob(t, dir, "frase_a.png", "a1.png")
+	a2 := fixtureJob(t, dir, "frase_a.png", "a2.png")
+	b1 := fixtureJob(t, dir, "frase_b.png", "b1.png")
+	b2 := fixtureJob(t, dir, "frase_b.png", "b2.png")
+	broken := &imageJob{filename: "rota.png", imagePath: filepath.Join(dir, "rota.png")}
+	if err := os.WriteFile(broken.imagePath, []byte("no es una imagen"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a3 := fixtureJob(t, dir, "frase_a.png", "a3.png")
+
+	reps, clusters := dedupJobs([]*imageJob{a1, a2, b1, b2, broken, a3}, 0.05)
+
+	// La imagen rota se reconoce por su cuenta y corta la serie: a3 no se
+	// une a a1 aunque sea igual.
+	wantReps := []*imageJob{a1, b1, broken, a3}
+	if len(reps) != len(wantReps) {
+		t.Fatalf("representativas = %d, se esperaban %d", len(reps), len(wantReps))
+	}
+	for i := range wantReps {
+		if reps[i] != wantReps[i] {
+			t.Errorf("representativa %d = %s, se esperaba %s", i, reps[i].filename, wantReps[i].filename)
+		}
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("grupos con duplicados = %d, se esperaban 2", len(clusters))
+	}
+	if c := clusters[0]; c.rep != a1 || len(c.duplicates) != 1 || c.duplicates[0] != a2 {
+		t.Errorf("primer grupo = %s + %d duplicados, se esperaba a1 + [a2]", c.rep.filename, len(c.duplicates))
+	}
+	if c := clusters[1]; c.rep != b1 || len(c.duplicates) != 1 || c.duplicates[0] != b2 {
+		t.Errorf("segundo grupo = %s + %d duplicados, se esperaba b1 + [b2]", c.rep.filename, len(c.duplicates))
+	}
+}
+
+func TestCopyDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	m, err := manifest.Load(filepath.Join(dir, manifest.DefaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := func(name string) *imageJob {
+		return &imageJob{filename: name, textPath: filepath.Join(dir, name+".txt")}
+	}
+	a1, a2, a3 := job("a1.png"), job("a2.png"), job("a3.png")
+	b1, b2 := job("b1.png"), job("b2.png")
+	if err := os.WriteFile(a1.textPath, []byte("Hola"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	failed := map[string]error{"b1.png": errors.New("cuota agotada")}
+
+	copied := copyDuplicates(m, []dedupCluster{
+		{rep: a1, duplicates: []*imageJob{a2, a3}},
+		{rep: b1, duplicates: []*imageJob{b2}},
+	}, failed)
+
+	if copied != 2 {
+		t.Errorf("textos copiados = %d, se esperaban 2", copied)
+	}
+	for _, dup := range []*imageJob{a2, a3} {
+		if got, err := os.ReadFile(dup.textPath); err != nil || string(got) != "Hola" {
+			t.Errorf("texto de %s = %q (%v), se esperaba \"Hola\"", dup.filename, got, err)
+		}
+		entry, _ := m.Image(dup.filename)
+		if entry.Status != manifest.StatusDone || entry.Metadata["dedup_of"] != "a1.png" {
+			t.Errorf("entrada de %s = %+v, se esperaba hecha y duplicado de a1.png", dup.filename, entry)
+		}
+	}
+
+	// El duplicado de una representativa fallida también falla.
+	if failed["b2.png"] == nil {
+		t.Error("b2.png debería figurar entre las fallidas")
+	}
+	if entry, _ := m.Image("b2.png"); entry.Status != manifest.StatusFailed || entry.Error == "" {
+		t.Errorf("entrada de b2.png = %+v, se esperaba fallida con error", entry)
+	}
+	if _, err := os.Stat(b2.textPath); !os.IsNotExist(err) {
+		t.Errorf("no debería haberse escrito el texto de b2.png (Stat: %v)", err)
+	}
+}
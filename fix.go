@@ -0,0 +1,66 @@
+// fix.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yoshi70001/googleDocsOCR/geminifix"
+	"github.com/yoshi70001/googleDocsOCR/srtbuilder"
+)
+
+// runFix implementa el subcomando "fix": vuelve a pasar un subtítulo ya hecho
+// (SRT o WebVTT) por la corrección con Gemini, la fusión de repetidos y el
+// ajuste de tiempos, y lo escribe en el formato pedido.
+func runFix(args []string) {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	useGemini := fs.Bool("use-gemini", false, "Activar corrección de texto con Gemini")
+	geminiModel := fs.String("gemini-model", geminifix.DefaultCorrectionModel, "Modelo de Gemini para la corrección de texto (los modelos antiguos usan el formato LÍNEA N)")
+	output := fs.String("o", "", "Archivo de salida (por defecto, <entrada>_fixed con la extensión de -format)")
+	var outFlags outputFlags
+	outFlags.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: googleDocsOCR fix [flags] archivo.srt|archivo.vtt")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	opts, err := outFlags.options()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	input := fs.Arg(0)
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(input, filepath.Ext(input)) + "_fixed" + srtbuilder.Extension(opts.Format)
+	}
+
+	blocks, err := srtbuilder.ReadFile(input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("✓ Leídos %d subtítulos de '%s'.", len(blocks), input)
+
+	ctx := context.Background()
+	geminifix.CorrectionModel = *geminiModel
+	geminiClient := newCorrectionClient(ctx, *useGemini)
+	if geminiClient != nil {
+		defer geminiClient.Close()
+	}
+
+	blocks = srtbuilder.ProcessBlocks(ctx, blocks, geminiClient, nil, opts)
+	if err := srtbuilder.WriteFile(outputPath, blocks, opts); err != nil {
+		log.Fatalf("Fallo al escribir '%s': %v", outputPath, err)
+	}
+	log.Printf("✓ Subtítulo guardado en '%s'.", outputPath)
+}
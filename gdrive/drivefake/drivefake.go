@@ -0,0 +1,543 @@
+// Package drivefake ofrece un sustituto en proceso de la API de Google Drive v3
+// con lo justo para el truco de OCR (files.list, files.create con media,
+// files.export, files.update y files.delete), de forma que el paquete gdrive y el flujo de
+// main.go puedan probarse sin red.
+package drivefake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+const documentMimeType = "application/vnd.google-apps.document"
+
+// File es un archivo almacenado en el servidor falso.
+type File struct {
+	ID          string
+	Name        string
+	MimeType    string
+	Parents     []string
+	CreatedTime time.Time
+	Trashed     bool
+	// Content son los bytes subidos como media (la imagen, en el caso del OCR).
+	Content []byte
+	// Text es el resultado del "OCR" que devolverá files.export en text/plain.
+	Text string
+	// HTML es lo que devolverá files.export en text/html. Si está vacío se
+	// genera un párrafo por cada línea de Text.
+	HTML string
+}
+
+// Failure es un error que el servidor devolverá en lugar de atender una llamada.
+type Failure struct {
+	Status int
+	// Reason es el motivo de googleapi.Error (p. ej. "userRateLimitExceeded").
+	Reason string
+	// RetryAfter se envía como cabecera Retry-After si no está vacío.
+	RetryAfter string
+	// Truncate atiende la llamada, pero corta la conexión a mitad de la
+	// respuesta, como una red que se cae después de que Drive la procese.
+	// Status y Reason se ignoran.
+	Truncate bool
+}
+
+// OCRFunc calcula el texto que Google Docs "reconocería" en una imagen.
+type OCRFunc func(name string, image []byte) string
+
+// Server es un servidor HTTP que imita Drive v3.
+type Server struct {
+	URL string
+	// HTMLOCR, si no es nil, calcula la exportación text/html de los
+	// documentos creados a partir de una imagen.
+	HTMLOCR OCRFunc
+
+	srv *httptest.Server
+	ocr OCRFunc
+	now func() time.Time
+
+	mu       sync.Mutex
+	files    map[string]*File
+	nextID   int
+	calls    map[string]int
+	failures map[string][]Failure
+}
+
+// NewServer arranca un servidor vacío. Si ocr es nil se usa DefaultOCR.
+func NewServer(ocr OCRFunc) *Server {
+	if ocr == nil {
+		ocr = DefaultOCR
+	}
+	s := &Server{
+		ocr:      ocr,
+		now:      time.Now,
+		files:    make(map[string]*File),
+		calls:    make(map[string]int),
+		failures: make(map[string][]Failure),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// DefaultOCR devuelve un texto con la misma forma que la exportación a texto
+// plano de Google Docs: una cabecera de dos líneas seguida del texto.
+func DefaultOCR(name string, image []byte) string {
+	return "\ufeff\n\nTexto de " + name + "\n"
+}
+
+// Endpoint es la URL base que debe usarse con option.WithEndpoint o con la
+// variable de entorno GDRIVE_ENDPOINT del paquete gdrive.
+func (s *Server) Endpoint() string {
+	return s.URL + "/drive/v3/"
+}
+
+// ClientOptions devuelve las opciones para que drive.NewService use este servidor.
+func (s *Server) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(s.Endpoint()),
+		option.WithoutAuthentication(),
+	}
+}
+
+// Service crea un *drive.Service conectado a este servidor.
+func (s *Server) Service(ctx context.Context) (*drive.Service, error) {
+	return drive.NewService(ctx, s.ClientOptions()...)
+}
+
+// Close detiene el servidor.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// AddFile inserta un archivo directamente, útil para preparar escenarios
+// (por ejemplo, documentos huérfanos de una ejecución anterior).
+func (s *Server) AddFile(f File) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.ID == "" {
+		f.ID = s.newID()
+	}
+	if f.CreatedTime.IsZero() {
+		f.CreatedTime = s.now()
+	}
+	s.files[f.ID] = &f
+	return f.ID
+}
+
+// Files devuelve una copia de los archivos no borrados, ordenados por ID.
+func (s *Server) Files() []File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make([]File, 0, len(s.files))
+	for _, f := range s.files {
+		files = append(files, *f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	return files
+}
+
+// Calls devuelve cuántas veces se ha llamado a una operación ("list",
+// "create", "export", "delete" o "update").
+func (s *Server) Calls(op string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[op]
+}
+
+// Fail encola errores para las próximas llamadas a la operación op ("list",
+// "create", "export", "delete" o "update"). Cada fallo consume una llamada,
+// que cuenta en Calls pero no modifica el estado.
+func (s *Server) Fail(op string, failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[op] = append(s.failures[op], failures...)
+}
+
+// injectFailure cuenta la llamada y, si hay un fallo encolado para op, lo
+// escribe y devuelve true. Un fallo Truncate no se escribe aquí: se devuelve
+// para que serveHTTP atienda la llamada y corte la respuesta.
+func (s *Server) injectFailure(w http.ResponseWriter, op string) (handled, truncate bool) {
+	s.mu.Lock()
+	s.calls[op]++
+	queue := s.failures[op]
+	if len(queue) == 0 {
+		s.mu.Unlock()
+		return false, false
+	}
+	failure := queue[0]
+	s.failures[op] = queue[1:]
+	s.mu.Unlock()
+
+	if failure.Truncate {
+		return false, true
+	}
+
+	if failure.RetryAfter != "" {
+		w.Header().Set("Retry-After", failure.RetryAfter)
+	}
+	writeError(w, failure.Status, failure.Reason, fmt.Sprintf("fallo simulado en %s", op))
+	return true, false
+}
+
+// truncatedResponse guarda la respuesta de un manejador para enviar solo la
+// primera mitad del cuerpo y cortar la conexión.
+type truncatedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (t *truncatedResponse) WriteHeader(status int) { t.status = status }
+
+func (t *truncatedResponse) Write(b []byte) (int, error) { return t.body.Write(b) }
+
+// send escribe la mitad de la respuesta anunciando su longitud completa y
+// aborta la conexión.
+func (t *truncatedResponse) send() {
+	w := t.ResponseWriter
+	w.Header().Set("Content-Length", strconv.Itoa(t.body.Len()))
+	if t.status != 0 {
+		w.WriteHeader(t.status)
+	}
+	w.Write(t.body.Bytes()[:t.body.Len()/2])
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// newID genera un ID único. Debe llamarse con s.mu tomado.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("fake-%04d", s.nextID)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.Path
+	if op := operation(r.Method, p); op != "" {
+		handled, truncate := s.injectFailure(w, op)
+		if handled {
+			return
+		}
+		if truncate {
+			t := &truncatedResponse{ResponseWriter: w}
+			defer t.send()
+			w = t
+		}
+	}
+	switch {
+	case r.Method == http.MethodGet && p == "/drive/v3/files":
+		s.handleList(w, r)
+	case r.Method == http.MethodPost && (p == "/drive/v3/files" || p == "/upload/drive/v3/files"):
+		s.handleCreate(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(p, "/drive/v3/files/") && strings.HasSuffix(p, "/export"):
+		s.handleExport(w, r, path.Base(path.Dir(p)))
+	case r.Method == http.MethodDelete && strings.HasPrefix(p, "/drive/v3/files/"):
+		s.handleDelete(w, path.Base(p))
+	case r.Method == http.MethodPatch && strings.HasPrefix(p, "/drive/v3/files/"):
+		s.handleUpdate(w, r, path.Base(p))
+	default:
+		writeError(w, http.StatusNotFound, "notFound", fmt.Sprintf("ruta no soportada: %s %s", r.Method, p))
+	}
+}
+
+// operation devuelve el nombre de la operación de Drive de una petición.
+func operation(method, p string) string {
+	switch {
+	case method == http.MethodGet && p == "/drive/v3/files":
+		return "list"
+	case method == http.MethodPost:
+		return "create"
+	case method == http.MethodGet && strings.HasSuffix(p, "/export"):
+		return "export"
+	case method == http.MethodDelete:
+		return "delete"
+	case method == http.MethodPatch:
+		return "update"
+	}
+	return ""
+}
+
+// fileJSON es la representación de un archivo en las respuestas.
+type fileJSON struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name,omitempty"`
+	MimeType    string   `json:"mimeType,omitempty"`
+	Parents     []string `json:"parents,omitempty"`
+	CreatedTime string   `json:"createdTime,omitempty"`
+	Trashed     bool     `json:"trashed,omitempty"`
+}
+
+func toJSON(f *File) fileJSON {
+	return fileJSON{
+		ID:          f.ID,
+		Name:        f.Name,
+		MimeType:    f.MimeType,
+		Parents:     f.Parents,
+		CreatedTime: f.CreatedTime.UTC().Format(time.RFC3339Nano),
+		Trashed:     f.Trashed,
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidQuery", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	var matched []*File
+	for _, f := range s.files {
+		if filter(f) {
+			matched = append(matched, f)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	offset = min(offset, len(matched))
+	end := min(offset+pageSize, len(matched))
+
+	resp := struct {
+		Files         []fileJSON `json:"files"`
+		NextPageToken string     `json:"nextPageToken,omitempty"`
+	}{Files: []fileJSON{}}
+	for _, f := range matched[offset:end] {
+		resp.Files = append(resp.Files, toJSON(f))
+	}
+	if end < len(matched) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var meta fileJSON
+	var content []byte
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		metaPart, err := mr.NextPart()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("multipart inválido: %v", err))
+			return
+		}
+		if err := json.NewDecoder(metaPart).Decode(&meta); err != nil {
+			writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("metadatos inválidos: %v", err))
+			return
+		}
+		mediaPart, err := mr.NextPart()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("falta la parte de media: %v", err))
+			return
+		}
+		if content, err = io.ReadAll(mediaPart); err != nil {
+			writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+			return
+		}
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&meta); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("metadatos inválidos: %v", err))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, parent := range meta.Parents {
+		if _, ok := s.files[parent]; !ok {
+			writeError(w, http.StatusNotFound, "notFound", fmt.Sprintf("carpeta padre no encontrada: %s", parent))
+			return
+		}
+	}
+	f := &File{
+		ID:          s.newID(),
+		Name:        meta.Name,
+		MimeType:    meta.MimeType,
+		Parents:     meta.Parents,
+		CreatedTime: s.now(),
+		Content:     content,
+	}
+	if f.MimeType == documentMimeType && content != nil {
+		f.Text = s.ocr(f.Name, content)
+		if s.HTMLOCR != nil {
+			f.HTML = s.HTMLOCR(f.Name, content)
+		}
+	}
+	s.files[f.ID] = f
+	writeJSON(w, toJSON(f))
+}
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	f, ok := s.files[id]
+	var text, htmlText string
+	if ok {
+		text, htmlText = f.Text, f.HTML
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "notFound", fmt.Sprintf("archivo no encontrado: %s", id))
+		return
+	}
+	switch mimeType := r.URL.Query().Get("mimeType"); mimeType {
+	case "text/plain":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, text)
+	case "text/html":
+		if htmlText == "" {
+			htmlText = textToHTML(text)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, htmlText)
+	default:
+		writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("tipo de exportación no soportado: %s", mimeType))
+	}
+}
+
+// textToHTML imita la exportación HTML de Docs para un texto sin formato: la
+// cabecera de la exportación de texto plano no aparece y cada línea es un párrafo.
+func textToHTML(text string) string {
+	var b strings.Builder
+	b.WriteString(`<html><head><meta content="text/html; charset=UTF-8" http-equiv="content-type"><style type="text/css">.c0{color:#000000;font-weight:400}</style></head><body>`)
+	for _, line := range strings.Split(strings.TrimPrefix(text, "\ufeff"), "\n") {
+		fmt.Fprintf(&b, `<p class="c0"><span>%s</span></p>`, html.EscapeString(line))
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	_, ok := s.files[id]
+	delete(s.files, id)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "notFound", fmt.Sprintf("archivo no encontrado: %s", id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request, id string) {
+	var patch struct {
+		Trashed *bool `json:"trashed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("metadatos inválidos: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "notFound", fmt.Sprintf("archivo no encontrado: %s", id))
+		return
+	}
+	if patch.Trashed != nil {
+		f.Trashed = *patch.Trashed
+	}
+	writeJSON(w, toJSON(f))
+}
+
+// parseQuery entiende el subconjunto del lenguaje de consultas de Drive que usa
+// gdrive: cláusulas unidas con "and" del tipo campo='valor', 'id' in parents,
+// trashed=true|false y createdTime < 'RFC3339'.
+func parseQuery(q string) (func(*File) bool, error) {
+	var preds []func(*File) bool
+	if strings.TrimSpace(q) == "" {
+		return func(*File) bool { return true }, nil
+	}
+	for _, clause := range strings.Split(q, " and ") {
+		clause = strings.TrimSpace(clause)
+		switch {
+		case strings.HasSuffix(clause, " in parents"):
+			parent := unquote(strings.TrimSuffix(clause, " in parents"))
+			preds = append(preds, func(f *File) bool {
+				for _, p := range f.Parents {
+					if p == parent {
+						return true
+					}
+				}
+				return false
+			})
+		case strings.HasPrefix(clause, "name="):
+			name := unquote(strings.TrimPrefix(clause, "name="))
+			preds = append(preds, func(f *File) bool { return f.Name == name })
+		case strings.HasPrefix(clause, "mimeType="):
+			mimeType := unquote(strings.TrimPrefix(clause, "mimeType="))
+			preds = append(preds, func(f *File) bool { return f.MimeType == mimeType })
+		case strings.HasPrefix(clause, "trashed="):
+			trashed := strings.TrimPrefix(clause, "trashed=") == "true"
+			preds = append(preds, func(f *File) bool { return f.Trashed == trashed })
+		case strings.HasPrefix(clause, "createdTime < "):
+			t, err := time.Parse(time.RFC3339, unquote(strings.TrimPrefix(clause, "createdTime < ")))
+			if err != nil {
+				return nil, fmt.Errorf("fecha inválida en la consulta: %v", err)
+			}
+			preds = append(preds, func(f *File) bool { return f.CreatedTime.Before(t) })
+		default:
+			return nil, fmt.Errorf("cláusula no soportada: %q", clause)
+		}
+	}
+	return func(f *File) bool {
+		for _, pred := range preds {
+			if !pred(f) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "'")
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError escribe un error con el formato de googleapi.Error.
+func writeError(w http.ResponseWriter, status int, reason, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": message,
+			"errors": []any{
+				map[string]any{"domain": "global", "reason": reason, "message": message},
+			},
+		},
+	})
+}
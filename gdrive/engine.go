@@ -0,0 +1,190 @@
+// gdrive/engine.go
+package gdrive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/yoshi70001/googleDocsOCR/ocr"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/drive/v3"
+)
+
+// EngineName es el nombre con el que se selecciona este motor en el flag -engine.
+const EngineName = "gdrive"
+
+// MetadataDocID es la clave de metadatos con el ID del documento temporal,
+// tanto en el resultado como en el aviso de ocr.NotifyRemote.
+const MetadataDocID = "drive_doc_id"
+
+// Engine implementa ocr.Engine usando el "truco" de Google Docs: subir la imagen
+// convirtiéndola en documento, exportar el texto y borrar el documento.
+type Engine struct {
+	// Retry es la política de reintentos de create, export y delete.
+	Retry RetryPolicy
+	// Limiter, si no es nil, limita las peticiones por segundo a Drive. Debe
+	// compartirse entre todos los workers para que el límite sea global.
+	Limiter *rate.Limiter
+	// ExportHTML exporta el documento como text/html en lugar de text/plain
+	// para conservar la cursiva y la negrita como etiquetas <i> y <b>.
+	ExportHTML bool
+
+	srv      *drive.Service
+	folderID string
+}
+
+// NewEngine crea un motor que usa la carpeta de Drive indicada para los
+// documentos temporales y DefaultRetryPolicy para los reintentos.
+func NewEngine(srv *drive.Service, driveFolderID string) *Engine {
+	return &Engine{Retry: DefaultRetryPolicy, srv: srv, folderID: driveFolderID}
+}
+
+// Name devuelve el identificador del motor.
+func (e *Engine) Name() string {
+	return EngineName
+}
+
+// AcceptsFormat indica si Google Docs puede hacer OCR de imágenes en ese
+// formato al convertirlas en documento: JPEG, PNG y GIF.
+func (e *Engine) AcceptsFormat(format string) bool {
+	return format == "jpeg" || format == "png" || format == "gif"
+}
+
+// call ejecuta una llamada a Drive con la política de reintentos, esperando
+// turno en el limitador antes de cada intento.
+func (e *Engine) call(ctx context.Context, op string, fn func() error) error {
+	return e.Retry.Do(ctx, op, func() error {
+		if e.Limiter != nil {
+			if err := e.Limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		return fn()
+	})
+}
+
+// DeleteDoc borra un documento temporal. Un documento que ya no existe no es
+// un error: puede que un intento anterior lo borrara y se perdiera la respuesta.
+func (e *Engine) DeleteDoc(ctx context.Context, id string) error {
+	return e.call(ctx, "files.delete", func() error {
+		err := e.srv.Files.Delete(id).Context(ctx).Do()
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// deleteLostDocs borra los documentos de la carpeta temporal con el nombre
+// dado, que solo puede ser el de un intento de files.create cuya respuesta se
+// perdió. Es lo mejor que se puede hacer: lo que no se encuentre aquí lo
+// borrará el barrido de huérfanos.
+func (e *Engine) deleteLostDocs(ctx context.Context, name string) {
+	query := fmt.Sprintf("name='%s' and '%s' in parents and trashed=false", queryEscaper.Replace(name), e.folderID)
+	var lost *drive.FileList
+	err := e.call(ctx, "files.list", func() error {
+		var err error
+		lost, err = e.srv.Files.List().Q(query).Fields("files(id)").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		log.Printf("    [!] No se pudo buscar el doc del intento fallido '%s': %v", name, err)
+		return
+	}
+	for _, f := range lost.Files {
+		log.Printf("    - Borrando el doc del intento fallido (ID: %s)...", f.Id)
+		if err := e.DeleteDoc(ctx, f.Id); err != nil {
+			log.Printf("    [!] No se pudo borrar el doc %s: %v", f.Id, err)
+		}
+	}
+}
+
+// queryEscaper escapa un valor para ponerlo entre comillas simples en una
+// consulta de Drive.
+var queryEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// Recognize realiza el OCR de una imagen a través de Google Docs.
+func (e *Engine) Recognize(ctx context.Context, image []byte, filename string) (*ocr.Result, error) {
+	// 1. El "truco" de OCR: Crear un Google Doc a partir de la imagen
+	// No es necesario subir la imagen primero, podemos hacerlo en un solo paso.
+	log.Printf("    - Paso 1/3: Realizando OCR (creando Google Doc desde la imagen)...")
+	docName := strings.TrimSuffix(filename, filepath.Ext(filename))
+	docMetadata := &drive.File{
+		Name:     docName,
+		Parents:  []string{e.folderID},
+		MimeType: "application/vnd.google-apps.document", // La clave del OCR
+	}
+
+	var doc *drive.File
+	var lastErr error
+	err := e.call(ctx, "files.create", func() error {
+		// files.create no es idempotente: si el intento anterior se cortó por
+		// la red, Drive pudo crear el doc aunque su ID nunca llegara.
+		if lastErr != nil && isTransportError(lastErr) {
+			e.deleteLostDocs(ctx, docName)
+		}
+		var err error
+		// Un lector nuevo en cada intento: el anterior ya se consumió.
+		doc, err = e.srv.Files.Create(docMetadata).Media(bytes.NewReader(image)).Fields("id").Context(ctx).Do()
+		lastErr = err
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo crear el Google Doc para OCR: %v", err)
+	}
+	// Avisar en cuanto existe el doc, por si el programa se interrumpe antes
+	// de borrarlo.
+	ocr.NotifyRemote(ctx, MetadataDocID, doc.Id)
+	// Usamos defer para asegurarnos de que el doc se borre al final.
+	defer func() {
+		log.Printf("    - Paso 3/3: Limpiando Google Doc temporal (ID: %s)...", doc.Id)
+		if err := e.DeleteDoc(ctx, doc.Id); err != nil {
+			log.Printf("ERROR: no se pudo borrar el doc temporal %s: %v", doc.Id, err)
+		}
+	}()
+
+	// 2. Exportar y descargar el contenido del Doc como texto plano (o HTML)
+	log.Printf("    - Paso 2/3: Descargando texto extraído...")
+	exportMimeType := "text/plain"
+	if e.ExportHTML {
+		exportMimeType = "text/html"
+	}
+	var res *http.Response
+	err = e.call(ctx, "files.export", func() error {
+		var err error
+		res, err = e.srv.Files.Export(doc.Id, exportMimeType).Context(ctx).Download()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo exportar el texto del Doc: %v", err)
+	}
+	defer res.Body.Close()
+
+	metadata := map[string]string{
+		MetadataDocID:   doc.Id,
+		"export_format": exportMimeType,
+	}
+	if e.ExportHTML {
+		text, err := docsHTMLToText(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &ocr.Result{Text: text, Metadata: metadata}, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer el cuerpo de la respuesta: %v", err)
+	}
+
+	return &ocr.Result{
+		Text:     string(body),
+		Metadata: metadata,
+	}, nil
+}
@@ -5,19 +5,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/yoshi70001/googleDocsOCR/ocr"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 )
 
+// EndpointEnv es la variable de entorno que, si está definida, apunta el cliente
+// de Drive a un servidor compatible con la API v3 (por ejemplo drivefake) y
+// omite la autenticación OAuth. Pensada para pruebas sin red.
+const EndpointEnv = "GDRIVE_ENDPOINT"
+
 // getExecutableDir devuelve la ruta absoluta del directorio del ejecutable.
 func getExecutableDir() (string, error) {
 	ex, err := os.Executable()
@@ -88,6 +92,11 @@ func saveToken(path string, token *oauth2.Token) {
 // AuthenticateAndGetService crea y devuelve un servicio de Drive autenticado.
 func AuthenticateAndGetService() (*drive.Service, error) {
 	ctx := context.Background()
+	if endpoint := os.Getenv(EndpointEnv); endpoint != "" {
+		log.Printf("[!] Usando el endpoint de Drive de %s: %s (sin autenticación)", EndpointEnv, endpoint)
+		return NewServiceForEndpoint(ctx, endpoint)
+	}
+
 	execDir, err := getExecutableDir()
 	if err != nil {
 		return nil, fmt.Errorf("no se pudo obtener el directorio del ejecutable: %v", err)
@@ -112,17 +121,36 @@ func AuthenticateAndGetService() (*drive.Service, error) {
 	return srv, nil
 }
 
-// GetOrCreateFolder busca una carpeta en Drive o la crea si no existe. Devuelve su ID.
-func GetOrCreateFolder(srv *drive.Service, folderName string) (string, error) {
+// NewServiceForEndpoint crea un servicio de Drive sin autenticación que habla con
+// el endpoint indicado en lugar de con Google.
+func NewServiceForEndpoint(ctx context.Context, endpoint string) (*drive.Service, error) {
+	srv, err := drive.NewService(ctx, option.WithEndpoint(endpoint), option.WithoutAuthentication())
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo crear el cliente de Drive para %s: %v", endpoint, err)
+	}
+	return srv, nil
+}
+
+// FindFolder busca una carpeta en Drive sin crearla. Devuelve su ID, o "" si
+// no existe.
+func FindFolder(srv *drive.Service, folderName string) (string, error) {
 	query := fmt.Sprintf("mimeType='application/vnd.google-apps.folder' and name='%s' and trashed=false", folderName)
 	r, err := srv.Files.List().Q(query).PageSize(1).Fields("files(id)").Do()
 	if err != nil {
 		return "", fmt.Errorf("no se pudo buscar la carpeta: %v", err)
 	}
+	if len(r.Files) == 0 {
+		return "", nil
+	}
+	log.Printf("Carpeta temporal '%s' encontrada con ID: %s", folderName, r.Files[0].Id)
+	return r.Files[0].Id, nil
+}
 
-	if len(r.Files) > 0 {
-		log.Printf("Carpeta temporal '%s' encontrada con ID: %s", folderName, r.Files[0].Id)
-		return r.Files[0].Id, nil
+// GetOrCreateFolder busca una carpeta en Drive o la crea si no existe. Devuelve su ID.
+func GetOrCreateFolder(srv *drive.Service, folderName string) (string, error) {
+	folderID, err := FindFolder(srv, folderName)
+	if err != nil || folderID != "" {
+		return folderID, err
 	}
 
 	log.Printf("Creando carpeta temporal en Drive: '%s'", folderName)
@@ -139,59 +167,6 @@ func GetOrCreateFolder(srv *drive.Service, folderName string) (string, error) {
 
 // ProcessImage realiza todo el proceso de OCR para una sola imagen.
 func ProcessImage(srv *drive.Service, imagePath, textOutputPath, driveFolderID string) error {
-	imageFileName := filepath.Base(imagePath)
-	log.Printf("[+] Iniciando procesamiento para: %s", imageFileName)
-
-	// Abrir el archivo de imagen local
-	imgFile, err := os.Open(imagePath)
-	if err != nil {
-		return fmt.Errorf("no se pudo abrir la imagen local %s: %v", imagePath, err)
-	}
-	defer imgFile.Close()
-
-	// 1. El "truco" de OCR: Crear un Google Doc a partir de la imagen
-	// No es necesario subir la imagen primero, podemos hacerlo en un solo paso.
-	log.Printf("    - Paso 1/3: Realizando OCR (creando Google Doc desde la imagen)...")
-	docName := strings.TrimSuffix(imageFileName, filepath.Ext(imageFileName))
-	docMetadata := &drive.File{
-		Name:     docName,
-		Parents:  []string{driveFolderID},
-		MimeType: "application/vnd.google-apps.document", // La clave del OCR
-	}
-
-	doc, err := srv.Files.Create(docMetadata).Media(imgFile).Fields("id").Do()
-	if err != nil {
-		return fmt.Errorf("no se pudo crear el Google Doc para OCR: %v", err)
-	}
-	// Usamos defer para asegurarnos de que el doc se borre al final.
-	defer func() {
-		log.Printf("    - Limpiando Google Doc temporal (ID: %s)...", doc.Id)
-		err := srv.Files.Delete(doc.Id).Do()
-		if err != nil {
-			log.Printf("ERROR: no se pudo borrar el doc temporal %s: %v", doc.Id, err)
-		}
-	}()
-
-	// 2. Exportar y descargar el contenido del Doc como texto plano
-	log.Printf("    - Paso 2/3: Descargando texto extraído...")
-	res, err := srv.Files.Export(doc.Id, "text/plain").Download()
-	if err != nil {
-		return fmt.Errorf("no se pudo exportar el texto del Doc: %v", err)
-	}
-	defer res.Body.Close()
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("no se pudo leer el cuerpo de la respuesta: %v", err)
-	}
-
-	// 3. Guardar el texto en un archivo local
-	log.Printf("    - Paso 3/3: Guardando texto en %s", textOutputPath)
-	err = os.WriteFile(textOutputPath, body, 0644)
-	if err != nil {
-		return fmt.Errorf("no se pudo guardar el archivo de texto: %v", err)
-	}
-
-	log.Printf("[✓] Procesamiento completado para: %s", imageFileName)
-	return nil
-}
\ No newline at end of file
+	_, err := ocr.ProcessFile(context.Background(), NewEngine(srv, driveFolderID), imagePath, textOutputPath)
+	return err
+}
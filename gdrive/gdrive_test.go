@@ -0,0 +1,536 @@
+package gdrive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/yoshi70001/googleDocsOCR/gdrive"
+	"github.com/yoshi70001/googleDocsOCR/gdrive/drivefake"
+	"github.com/yoshi70001/googleDocsOCR/ocr"
+	"golang.org/x/image/bmp"
+	"golang.org/x/time/rate"
+)
+
+func TestProcessImageAgainstFakeDrive(t *testing.T) {
+	fake := drivefake.NewServer(func(name string, image []byte) string {
+		return "\ufeff\n\n" + name + ": " + string(image)
+	})
+	defer fake.Close()
+	t.Setenv(gdrive.EndpointEnv, fake.Endpoint())
+
+	srv, err := gdrive.AuthenticateAndGetService()
+	if err != nil {
+		t.Fatalf("AuthenticateAndGetService: %v", err)
+	}
+
+	folderID, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatalf("GetOrCreateFolder (crear): %v", err)
+	}
+	again, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatalf("GetOrCreateFolder (buscar): %v", err)
+	}
+	if again != folderID {
+		t.Errorf("la segunda llamada devolvió %q, se esperaba la carpeta existente %q", again, folderID)
+	}
+	if n := fake.Calls("create"); n != 1 {
+		t.Errorf("se crearon %d carpetas, se esperaba 1", n)
+	}
+
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "0_00_01_000__0_00_02_000.png")
+	textPath := filepath.Join(dir, "0_00_01_000__0_00_02_000.txt")
+	if err := os.WriteFile(imagePath, []byte("pixeles"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gdrive.ProcessImage(srv, imagePath, textPath, folderID); err != nil {
+		t.Fatalf("ProcessImage: %v", err)
+	}
+
+	got, err := os.ReadFile(textPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0_00_01_000__0_00_02_000: pixeles"; !strings.HasSuffix(string(got), want) {
+		t.Errorf("texto = %q, se esperaba que terminara en %q", got, want)
+	}
+
+	// Solo debe quedar la carpeta: el documento temporal se borra al terminar.
+	files := fake.Files()
+	if len(files) != 1 || files[0].ID != folderID {
+		t.Errorf("archivos restantes en Drive = %+v", files)
+	}
+	if fake.Calls("export") != 1 || fake.Calls("delete") != 1 {
+		t.Errorf("export=%d delete=%d, se esperaba 1 de cada", fake.Calls("export"), fake.Calls("delete"))
+	}
+}
+
+func TestProcessImageMissingFolder(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	defer fake.Close()
+
+	srv, err := gdrive.NewServiceForEndpoint(t.Context(), fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "img.png")
+	if err := os.WriteFile(imagePath, []byte("pixeles"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gdrive.ProcessImage(srv, imagePath, filepath.Join(dir, "img.txt"), "no-existe"); err == nil {
+		t.Fatal("se esperaba un error al subir a una carpeta inexistente")
+	}
+}
+
+func TestSweepFolder(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	defer fake.Close()
+	ctx := t.Context()
+
+	srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folderID, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-3 * time.Hour)
+	orphanA := fake.AddFile(drivefake.File{Name: "huérfano A", Parents: []string{folderID}, CreatedTime: old})
+	orphanB := fake.AddFile(drivefake.File{Name: "huérfano B", Parents: []string{folderID}, CreatedTime: old})
+	recent := fake.AddFile(drivefake.File{Name: "en curso", Parents: []string{folderID}})
+	elsewhere := fake.AddFile(drivefake.File{Name: "otra carpeta", CreatedTime: old})
+
+	// El modo dry-run solo lista.
+	found, err := gdrive.SweepFolder(ctx, srv, folderID, gdrive.SweepOptions{OlderThan: time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("SweepFolder (dry-run): %v", err)
+	}
+	if len(found) != 2 || len(fake.Files()) != 5 {
+		t.Fatalf("dry-run encontró %d archivos y dejó %d en Drive", len(found), len(fake.Files()))
+	}
+
+	// A la papelera.
+	if _, err := gdrive.SweepFolder(ctx, srv, folderID, gdrive.SweepOptions{OlderThan: time.Hour, Trash: true}); err != nil {
+		t.Fatalf("SweepFolder (papelera): %v", err)
+	}
+	trashed := map[string]bool{}
+	for _, f := range fake.Files() {
+		trashed[f.ID] = f.Trashed
+	}
+	if !trashed[orphanA] || !trashed[orphanB] || trashed[recent] || trashed[elsewhere] {
+		t.Errorf("estado de papelera inesperado: %v", trashed)
+	}
+
+	// Los archivos en la papelera ya no se listan; el borrado definitivo solo
+	// encuentra el nuevo huérfano.
+	orphanC := fake.AddFile(drivefake.File{Name: "huérfano C", Parents: []string{folderID}, CreatedTime: old})
+	found, err = gdrive.SweepFolder(ctx, srv, folderID, gdrive.SweepOptions{OlderThan: time.Hour})
+	if err != nil {
+		t.Fatalf("SweepFolder (borrar): %v", err)
+	}
+	if len(found) != 1 || found[0].Id != orphanC {
+		t.Fatalf("borrado definitivo encontró %+v, se esperaba solo %s", found, orphanC)
+	}
+	for _, f := range fake.Files() {
+		if f.ID == orphanC {
+			t.Errorf("el huérfano %s sigue en Drive", orphanC)
+		}
+	}
+}
+
+func TestSweepFolderContinuesAfterErrors(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	defer fake.Close()
+	ctx := t.Context()
+
+	srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Un dry-run de cleanup busca la carpeta sin crearla.
+	if id, err := gdrive.FindFolder(srv, "Temp_OCR_Go"); err != nil || id != "" {
+		t.Fatalf("FindFolder = %q, %v; se esperaba \"\" sin error", id, err)
+	}
+	if n := len(fake.Files()); n != 0 {
+		t.Fatalf("FindFolder creó %d archivos en Drive", n)
+	}
+	folderID, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := gdrive.FindFolder(srv, "Temp_OCR_Go"); err != nil || id != folderID {
+		t.Fatalf("FindFolder = %q, %v; se esperaba %q", id, err, folderID)
+	}
+
+	old := time.Now().Add(-3 * time.Hour)
+	for _, name := range []string{"huérfano A", "huérfano B", "huérfano C"} {
+		fake.AddFile(drivefake.File{Name: name, Parents: []string{folderID}, CreatedTime: old})
+	}
+	// El primer borrado falla sin remedio y el segundo encuentra el archivo
+	// ya borrado; el tercero debe intentarse igualmente.
+	fake.Fail("delete",
+		drivefake.Failure{Status: 403, Reason: "insufficientFilePermissions"},
+		drivefake.Failure{Status: 404, Reason: "notFound"})
+
+	found, err := gdrive.SweepFolder(ctx, srv, folderID, gdrive.SweepOptions{OlderThan: time.Hour})
+	if err == nil || !strings.Contains(err.Error(), "no se pudo borrar") {
+		t.Errorf("error = %v, se esperaba el del borrado fallido", err)
+	}
+	if len(found) != 3 {
+		t.Errorf("se encontraron %d huérfanos, se esperaban 3", len(found))
+	}
+	if got := fake.Calls("delete"); got != 3 {
+		t.Errorf("delete llamado %d veces, se esperaban 3", got)
+	}
+	// Quedan la carpeta, el huérfano que no se pudo borrar y el del 404
+	// (el fallo simulado no lo borra).
+	if n := len(fake.Files()); n != 3 {
+		t.Errorf("quedaron %d archivos en Drive, se esperaban 3", n)
+	}
+
+	// Un 404 al mandar a la papelera tampoco es un error.
+	fake.Fail("update", drivefake.Failure{Status: 404, Reason: "notFound"})
+	if _, err := gdrive.SweepFolder(ctx, srv, folderID, gdrive.SweepOptions{OlderThan: time.Hour, Trash: true}); err != nil {
+		t.Errorf("SweepFolder (papelera con 404): %v", err)
+	}
+}
+
+// fastRetry evita esperas reales en las pruebas de reintentos.
+var fastRetry = gdrive.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestEngineRetriesTransientErrors(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	defer fake.Close()
+	ctx := t.Context()
+
+	srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folderID, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Fail("create",
+		drivefake.Failure{Status: 403, Reason: "userRateLimitExceeded"},
+		drivefake.Failure{Status: 429, Reason: "rateLimitExceeded", RetryAfter: "0"})
+	fake.Fail("export", drivefake.Failure{Status: 503, Reason: "backendError"})
+	fake.Fail("delete", drivefake.Failure{Status: 500, Reason: "internalError"})
+
+	engine := gdrive.NewEngine(srv, folderID)
+	engine.Retry = fastRetry
+	result, err := engine.Recognize(ctx, []byte("pixeles"), "img.png")
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+	if !strings.Contains(result.Text, "Texto de img") {
+		t.Errorf("texto = %q", result.Text)
+	}
+	// 1 carpeta + 3 intentos de documento.
+	if got := fake.Calls("create"); got != 4 {
+		t.Errorf("create llamado %d veces, se esperaban 4", got)
+	}
+	if fake.Calls("export") != 2 || fake.Calls("delete") != 2 {
+		t.Errorf("export=%d delete=%d, se esperaban 2 de cada", fake.Calls("export"), fake.Calls("delete"))
+	}
+	if n := len(fake.Files()); n != 1 {
+		t.Errorf("quedaron %d archivos en Drive, se esperaba solo la carpeta", n)
+	}
+}
+
+func TestEngineDoesNotRetryPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure drivefake.Failure
+		calls   int
+	}{
+		{"sin permisos", drivefake.Failure{Status: 403, Reason: "insufficientFilePermissions"}, 1},
+		{"petición inválida", drivefake.Failure{Status: 400, Reason: "badRequest"}, 1},
+		{"cuota agotada", drivefake.Failure{Status: 429, Reason: "rateLimitExceeded"}, fastRetry.MaxAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := drivefake.NewServer(nil)
+			defer fake.Close()
+			ctx := t.Context()
+
+			srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+			if err != nil {
+				t.Fatal(err)
+			}
+			folder := fake.AddFile(drivefake.File{Name: "Temp_OCR_Go", MimeType: "application/vnd.google-apps.folder"})
+
+			for range fastRetry.MaxAttempts {
+				fake.Fail("create", tt.failure)
+			}
+
+			engine := gdrive.NewEngine(srv, folder)
+			engine.Retry = fastRetry
+			if _, err := engine.Recognize(ctx, []byte("pixeles"), "img.png"); err == nil {
+				t.Fatal("se esperaba un error")
+			}
+			if got := fake.Calls("create"); got != tt.calls {
+				t.Errorf("create llamado %d veces, se esperaban %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+// temporaryErr es un error de red que se declara temporal, como los que
+// envuelve *url.Error.
+type temporaryErr struct{}
+
+func (temporaryErr) Error() string   { return "error temporal" }
+func (temporaryErr) Temporary() bool { return true }
+
+func TestRetryPolicyConnectionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		calls int
+	}{
+		{"conexión reiniciada", &url.Error{Op: "Post", URL: "https://www.googleapis.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}, fastRetry.MaxAttempts},
+		{"conexión reiniciada en Windows", &url.Error{Op: "Post", URL: "https://www.googleapis.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("wsarecv", syscall.Errno(10054))}}, fastRetry.MaxAttempts},
+		{"respuesta cortada", fmt.Errorf("leyendo la respuesta: %w", io.ErrUnexpectedEOF), fastRetry.MaxAttempts},
+		{"error temporal", &url.Error{Op: "Get", URL: "https://www.googleapis.com", Err: temporaryErr{}}, fastRetry.MaxAttempts},
+		{"cancelado", &url.Error{Op: "Get", URL: "https://www.googleapis.com", Err: context.Canceled}, 1},
+		{"otro error", errors.New("fallo permanente"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := fastRetry.Do(t.Context(), "files.create", func() error {
+				calls++
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, se esperaba %v", err, tt.err)
+			}
+			if calls != tt.calls {
+				t.Errorf("intentos = %d, se esperaban %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestEngineDeletesDocOfLostCreate(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	defer fake.Close()
+	ctx := t.Context()
+
+	srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder := fake.AddFile(drivefake.File{Name: "Temp_OCR_Go", MimeType: "application/vnd.google-apps.folder"})
+	// El primer create crea el doc, pero la respuesta se corta.
+	fake.Fail("create", drivefake.Failure{Truncate: true})
+
+	engine := gdrive.NewEngine(srv, folder)
+	engine.Retry = fastRetry
+	result, err := engine.Recognize(ctx, []byte("pixeles"), "img.png")
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+	if result.Text == "" {
+		t.Error("texto vacío")
+	}
+	if got := fake.Calls("create"); got != 2 {
+		t.Errorf("create llamado %d veces, se esperaban 2", got)
+	}
+	// Ni el doc del intento cortado ni el del bueno deben quedar en Drive.
+	for _, f := range fake.Files() {
+		if f.ID != folder {
+			t.Errorf("quedó el doc %s (%s) en Drive", f.ID, f.Name)
+		}
+	}
+}
+
+func TestEngineLimiterIsShared(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	defer fake.Close()
+	ctx := t.Context()
+
+	srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder := fake.AddFile(drivefake.File{Name: "Temp_OCR_Go", MimeType: "application/vnd.google-apps.folder"})
+	old := time.Now().Add(-3 * time.Hour)
+	fake.AddFile(drivefake.File{Name: "huérfano", Parents: []string{folder}, CreatedTime: old})
+
+	// Una ráfaga de 100 que prácticamente no se repone: cada petición que
+	// pase por el limitador gasta una ficha.
+	const burst = 100
+	engine := gdrive.NewEngine(srv, folder)
+	engine.Limiter = rate.NewLimiter(rate.Limit(1e-9), burst)
+
+	// Barrido: files.list y files.delete del huérfano.
+	if _, err := engine.Sweep(ctx, gdrive.SweepOptions{OlderThan: time.Hour}); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	// Varios workers con el mismo motor: create, export y delete cada uno.
+	const workers = 4
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := engine.Recognize(ctx, []byte("pixeles"), "img.png"); err != nil {
+				t.Errorf("Recognize: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	calls := fake.Calls("list") + fake.Calls("create") + fake.Calls("export") + fake.Calls("delete")
+	if calls != 2+3*workers {
+		t.Errorf("peticiones a Drive = %d, se esperaban %d", calls, 2+3*workers)
+	}
+	if used := burst - int(math.Round(engine.Limiter.Tokens())); used != calls {
+		t.Errorf("fichas gastadas = %d, se esperaba una por petición (%d)", used, calls)
+	}
+}
+
+// docsHTML imita la exportación text/html de Google Docs: el formato va en
+// clases CSS y el texto se reparte en varios <span>.
+const docsHTML = `<html><head><meta content="text/html; charset=UTF-8" http-equiv="content-type">` +
+	`<style type="text/css">.c0{color:#000000;font-weight:400;font-style:normal}` +
+	`.c1{font-style:italic}.c2{font-weight:700}.c3{margin-left:36pt}</style></head>` +
+	`<body class="c3"><p class="c3"><span class="c0"></span></p>` +
+	`<p class="c3"><span class="c0 c1">♪ Bajo la </span><span class="c1">luna</span><span class="c0"> llena ♪</span></p>` +
+	`<p><span class="c2">¡Corre</span><span>, </span><span style="font-weight:bold;font-style:italic">ahora</span>` +
+	`<span class="c0">!</span></p><p><span>A &amp; B</span></p></body></html>`
+
+func TestEngineExportHTML(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	fake.HTMLOCR = func(name string, image []byte) string { return docsHTML }
+	defer fake.Close()
+	ctx := t.Context()
+
+	srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folderID, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := gdrive.NewEngine(srv, folderID)
+	engine.ExportHTML = true
+	result, err := engine.Recognize(ctx, []byte("pixeles"), "img.png")
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+
+	want := "<i>♪ Bajo la luna</i> llena ♪\n<b>¡Corre</b>, <i><b>ahora</b></i>!\nA & B"
+	if result.Text != want {
+		t.Errorf("texto = %q, se esperaba %q", result.Text, want)
+	}
+	if got := result.Metadata["export_format"]; got != "text/html" {
+		t.Errorf("export_format = %q, se esperaba text/html", got)
+	}
+}
+
+func TestEngineConvertsUnsupportedFormats(t *testing.T) {
+	var received []string
+	fake := drivefake.NewServer(func(name string, image []byte) string {
+		received = append(received, http.DetectContentType(image))
+		return name
+	})
+	defer fake.Close()
+	ctx := t.Context()
+
+	srv, err := gdrive.NewServiceForEndpoint(ctx, fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folderID, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := gdrive.NewEngine(srv, folderID)
+
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	encoders := map[string]func(io.Writer, image.Image) error{
+		"a.bmp": bmp.Encode,
+		"b.png": png.Encode,
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.bmp", "b.png"} {
+		var buf bytes.Buffer
+		if err := encoders[name](&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		imagePath := filepath.Join(dir, name)
+		if err := os.WriteFile(imagePath, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ocr.ProcessFile(ctx, engine, imagePath, imagePath+".txt"); err != nil {
+			t.Fatalf("ProcessFile(%s): %v", name, err)
+		}
+	}
+
+	// Drive no hace OCR de BMP: debe llegarle convertido a PNG.
+	if want := []string{"image/png", "image/png"}; !slices.Equal(received, want) {
+		t.Errorf("formatos recibidos = %v, se esperaba %v", received, want)
+	}
+}
+
+func TestEngineNotifiesDocBeforeDeleting(t *testing.T) {
+	fake := drivefake.NewServer(nil)
+	defer fake.Close()
+
+	srv, err := gdrive.NewServiceForEndpoint(t.Context(), fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folderID, err := gdrive.GetOrCreateFolder(srv, "Temp_OCR_Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notified string
+	ctx := ocr.WithRemoteHook(t.Context(), func(key, id string) {
+		if key != gdrive.MetadataDocID {
+			t.Errorf("clave = %q, se esperaba %q", key, gdrive.MetadataDocID)
+		}
+		notified = id
+		// El aviso llega mientras el documento todavía existe.
+		if n := len(fake.Files()); n != 2 {
+			t.Errorf("hay %d archivos en Drive al avisar, se esperaba la carpeta y el documento", n)
+		}
+	})
+	result, err := gdrive.NewEngine(srv, folderID).Recognize(ctx, []byte("pixeles"), "img.png")
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+	if notified == "" || notified != result.Metadata[gdrive.MetadataDocID] {
+		t.Errorf("ID avisado = %q, se esperaba el del resultado %q", notified, result.Metadata[gdrive.MetadataDocID])
+	}
+}
@@ -0,0 +1,175 @@
+// gdrive/html.go
+package gdrive
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cssRuleRe captura las reglas de clase de la hoja de estilos que genera
+// Google Docs, del tipo ".c3{font-style:italic;color:#000000}".
+var cssRuleRe = regexp.MustCompile(`\.([A-Za-z0-9_-]+)\s*\{([^}]*)\}`)
+
+// textStyle es el formato de un fragmento de texto que conservamos.
+type textStyle struct {
+	italic bool
+	bold   bool
+}
+
+// parseCSSDeclarations aplica sobre s las declaraciones "prop:valor;..." que
+// nos interesan.
+func parseCSSDeclarations(s textStyle, decls string) textStyle {
+	for _, decl := range strings.Split(decls, ";") {
+		prop, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch prop {
+		case "font-style":
+			s.italic = value == "italic" || value == "oblique"
+		case "font-weight":
+			if n, err := strconv.Atoi(value); err == nil {
+				s.bold = n >= 600
+			} else {
+				s.bold = value == "bold" || value == "bolder"
+			}
+		}
+	}
+	return s
+}
+
+// htmlConverter recorre el HTML exportado por Docs y produce texto plano con
+// etiquetas <i> y <b> de SRT.
+type htmlConverter struct {
+	classes    map[string]string
+	paragraphs []string
+	current    strings.Builder
+}
+
+// styleOf calcula el estilo de un elemento a partir de sus clases y de su
+// atributo style, heredando el del padre.
+func (c *htmlConverter) styleOf(n *html.Node, parent textStyle) textStyle {
+	s := parent
+	switch n.DataAtom {
+	case atom.I, atom.Em:
+		s.italic = true
+	case atom.B, atom.Strong:
+		s.bold = true
+	}
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "class":
+			for _, class := range strings.Fields(attr.Val) {
+				s = parseCSSDeclarations(s, c.classes[class])
+			}
+		case "style":
+			s = parseCSSDeclarations(s, attr.Val)
+		}
+	}
+	return s
+}
+
+// writeText añade texto al párrafo actual envuelto en las etiquetas de su estilo.
+// Los fragmentos de solo espacios no se etiquetan.
+func (c *htmlConverter) writeText(text string, s textStyle) {
+	if strings.TrimSpace(text) == "" {
+		c.current.WriteString(text)
+		return
+	}
+	if s.bold {
+		text = "<b>" + text + "</b>"
+	}
+	if s.italic {
+		text = "<i>" + text + "</i>"
+	}
+	c.current.WriteString(text)
+}
+
+// endParagraph cierra el párrafo actual.
+func (c *htmlConverter) endParagraph() {
+	p := c.current.String()
+	// Docs divide el texto en varios <span> aunque tengan el mismo formato.
+	for _, tag := range []string{"i", "b"} {
+		p = strings.ReplaceAll(p, "</"+tag+"><"+tag+">", "")
+	}
+	// Los párrafos vacíos romperían el bloque SRT con una línea en blanco.
+	if p = strings.TrimSpace(p); p != "" {
+		c.paragraphs = append(c.paragraphs, p)
+	}
+	c.current.Reset()
+}
+
+// walk recorre el árbol en orden, acumulando el texto de cada bloque.
+func (c *htmlConverter) walk(n *html.Node, s textStyle) {
+	switch n.Type {
+	case html.TextNode:
+		// Los saltos de línea del código HTML no son saltos del documento.
+		c.writeText(strings.ReplaceAll(n.Data, "\n", " "), s)
+		return
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Head, atom.Script, atom.Style, atom.Title:
+			return
+		case atom.Br:
+			c.current.WriteString("\n")
+			return
+		}
+		s = c.styleOf(n, s)
+	}
+
+	block := n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Li ||
+		n.DataAtom == atom.H1 || n.DataAtom == atom.H2 || n.DataAtom == atom.H3 ||
+		n.DataAtom == atom.Div || n.DataAtom == atom.Tr)
+	if block && c.current.Len() > 0 {
+		c.endParagraph()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child, s)
+	}
+	if block {
+		c.endParagraph()
+	}
+}
+
+// collectStyles lee las reglas de clase de los <style> del documento.
+func collectStyles(n *html.Node, classes map[string]string) {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Style {
+		var css strings.Builder
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			css.WriteString(child.Data)
+		}
+		for _, m := range cssRuleRe.FindAllStringSubmatch(css.String(), -1) {
+			classes[m[1]] += ";" + m[2]
+		}
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		collectStyles(child, classes)
+	}
+}
+
+// docsHTMLToText convierte la exportación text/html de un Google Doc en texto
+// plano, conservando la cursiva y la negrita como etiquetas <i> y <b> y
+// descartando el resto del formato. Cada párrafo va en su propia línea.
+func docsHTMLToText(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo analizar el HTML exportado: %v", err)
+	}
+
+	c := &htmlConverter{classes: make(map[string]string)}
+	collectStyles(doc, c.classes)
+	c.walk(doc, textStyle{})
+	if c.current.Len() > 0 {
+		c.endParagraph()
+	}
+	return strings.TrimSpace(strings.Join(c.paragraphs, "\n")), nil
+}
@@ -0,0 +1,154 @@
+// gdrive/retry.go
+package gdrive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// RetryPolicy define cómo se reintentan las llamadas a Drive que fallan por
+// límites de cuota o errores transitorios del servidor.
+type RetryPolicy struct {
+	// MaxAttempts es el número total de intentos, incluido el primero.
+	MaxAttempts int
+	// BaseDelay es la espera antes del primer reintento; se duplica en cada uno.
+	BaseDelay time.Duration
+	// MaxDelay limita la espera entre intentos.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy sigue la recomendación de Google para Drive: backoff
+// exponencial con jitter, hasta unos 30 segundos entre intentos.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 6,
+	BaseDelay:   time.Second,
+	MaxDelay:    32 * time.Second,
+}
+
+// rateLimitReasons son los motivos de un 403 que indican cuota agotada y no
+// falta de permisos.
+var rateLimitReasons = map[string]bool{
+	"userRateLimitExceeded": true,
+	"rateLimitExceeded":     true,
+	"backendError":          true,
+}
+
+// wsaeconnreset es WSAECONNRESET, el error con que Windows informa de una
+// conexión reiniciada. Allí syscall.ECONNRESET es un número inventado por Go
+// que la red nunca devuelve.
+const wsaeconnreset = syscall.Errno(10054)
+
+// isRetryable indica si un error de Drive es transitorio.
+func isRetryable(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		case http.StatusForbidden:
+			for _, item := range apiErr.Errors {
+				if rateLimitReasons[item.Reason] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return isTransportError(err)
+}
+
+// isTransportError indica si un error es un fallo transitorio de la red, no
+// una respuesta de Drive: la petición pudo llegar a procesarse o no.
+func isTransportError(err error) bool {
+	// Conexiones cortadas por el servidor o un proxy a mitad de la petición.
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, wsaeconnreset) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if temp, ok := urlErr.Err.(interface{ Temporary() bool }); ok && temp.Temporary() {
+			return true
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isNotFound indica si el error es un 404 de Drive.
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// retryAfter devuelve la espera pedida por el servidor en la cabecera Retry-After.
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Header == nil {
+		return 0, false
+	}
+	value := apiErr.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// backoff calcula la espera antes del reintento número attempt (empezando en 1),
+// con jitter entre la mitad y el total para no sincronizar a los workers.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// Do ejecuta fn hasta que tenga éxito, devuelva un error no reintentable o se
+// agoten los intentos. op se usa solo en los logs.
+func (p RetryPolicy) Do(ctx context.Context, op string, fn func() error) error {
+	attempts := max(p.MaxAttempts, 1)
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !isRetryable(err) || attempt >= attempts {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		if wait, ok := retryAfter(err); ok {
+			delay = wait
+		}
+		log.Printf("    [!] %s falló (intento %d/%d): %v. Reintentando en %s...", op, attempt, attempts, err, delay.Round(time.Millisecond))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// gdrive/sweep.go
+package gdrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// SweepOptions controla qué hace SweepFolder con los documentos huérfanos.
+type SweepOptions struct {
+	// OlderThan es la antigüedad mínima para considerar huérfano un archivo.
+	// Un documento de OCR vive unos segundos, así que cualquier margen
+	// razonable evita tocar los de otra ejecución en curso.
+	OlderThan time.Duration
+	// DryRun solo lista los archivos sin modificarlos.
+	DryRun bool
+	// Trash mueve los archivos a la papelera en lugar de borrarlos definitivamente.
+	Trash bool
+}
+
+// SweepFolder es Engine.Sweep con un motor nuevo para la carpeta indicada,
+// con DefaultRetryPolicy y sin limitador.
+func SweepFolder(ctx context.Context, srv *drive.Service, driveFolderID string, opts SweepOptions) ([]*drive.File, error) {
+	return NewEngine(srv, driveFolderID).Sweep(ctx, opts)
+}
+
+// Sweep busca en la carpeta temporal del motor los archivos más antiguos que
+// opts.OlderThan (documentos que quedaron al interrumpirse ProcessImage entre
+// la creación y el borrado) y los borra o los manda a la papelera. Cada
+// petición pasa por la política de reintentos y el limitador del motor.
+// Devuelve los archivos encontrados.
+func (e *Engine) Sweep(ctx context.Context, opts SweepOptions) ([]*drive.File, error) {
+	cutoff := time.Now().Add(-opts.OlderThan).UTC().Format(time.RFC3339)
+	query := fmt.Sprintf("'%s' in parents and trashed=false and createdTime < '%s'", e.folderID, cutoff)
+
+	var orphans []*drive.File
+	pageToken := ""
+	for {
+		var page *drive.FileList
+		err := e.call(ctx, "files.list", func() error {
+			var err error
+			page, err = e.srv.Files.List().Q(query).
+				Fields("nextPageToken, files(id, name, createdTime)").
+				PageSize(100).
+				PageToken(pageToken).
+				Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo listar la carpeta temporal: %v", err)
+		}
+		orphans = append(orphans, page.Files...)
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	// Un fallo no detiene la limpieza del resto: se devuelven todos juntos.
+	var errs []error
+	for _, f := range orphans {
+		var err error
+		switch {
+		case opts.DryRun:
+			log.Printf("    - [DRY-RUN] %s (ID: %s, creado: %s)", f.Name, f.Id, f.CreatedTime)
+		case opts.Trash:
+			log.Printf("    - Moviendo a la papelera: %s (ID: %s, creado: %s)", f.Name, f.Id, f.CreatedTime)
+			err = e.call(ctx, "files.update", func() error {
+				_, err := e.srv.Files.Update(f.Id, &drive.File{Trashed: true}).Context(ctx).Do()
+				// Ya no existe: otra limpieza se adelantó.
+				if isNotFound(err) {
+					return nil
+				}
+				return err
+			})
+			if err != nil {
+				err = fmt.Errorf("no se pudo mover a la papelera %s: %v", f.Id, err)
+			}
+		default:
+			log.Printf("    - Borrando: %s (ID: %s, creado: %s)", f.Name, f.Id, f.CreatedTime)
+			if err = e.DeleteDoc(ctx, f.Id); err != nil {
+				err = fmt.Errorf("no se pudo borrar %s: %v", f.Id, err)
+			}
+		}
+		if err != nil {
+			log.Printf("    [!] %v", err)
+			errs = append(errs, err)
+		}
+	}
+	return orphans, errors.Join(errs...)
+}
@@ -0,0 +1,223 @@
+// Package geminifake ofrece un sustituto local de la API de Generative Language
+// (generateContent) para probar el código que usa Gemini sin red ni API key real.
+package geminifake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+// Request es una llamada a generateContent recibida por el servidor.
+type Request struct {
+	Model  string
+	Texts  []string
+	Images []Image
+	// ResponseMIMEType es el tipo de respuesta pedido en generationConfig
+	// ("application/json" cuando se usa un esquema de respuesta).
+	ResponseMIMEType string
+}
+
+// Image es una imagen enviada en línea (inlineData) dentro de una petición.
+type Image struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Reply describe la respuesta del servidor a una petición.
+type Reply struct {
+	// Text es el texto del único candidato devuelto.
+	Text string
+	// Raw, si no está vacío, se escribe tal cual como cuerpo de la respuesta
+	// en lugar de construir el JSON, para simular respuestas malformadas.
+	Raw string
+	// Status es el código HTTP; 0 equivale a 200. Con un código de error se
+	// devuelve un error con el formato de las APIs de Google.
+	Status int
+	// RetryAfter se envía como cabecera Retry-After si no está vacío.
+	RetryAfter string
+	// Delay retrasa la respuesta, respetando la cancelación del cliente.
+	Delay time.Duration
+}
+
+// Handler decide qué responde el modelo para cada petición.
+type Handler func(req Request) Reply
+
+// Server es un servidor HTTP en proceso que imita el endpoint de Gemini.
+type Server struct {
+	URL string
+
+	srv     *httptest.Server
+	handler Handler
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+// NewServer arranca un servidor que responde usando handler.
+func NewServer(handler Handler) *Server {
+	s := &Server{handler: handler}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Text devuelve un Handler que siempre responde con el mismo texto.
+func Text(text string) Handler {
+	return func(Request) Reply { return Reply{Text: text} }
+}
+
+// RateLimited es una respuesta 429 como la que da la API al agotar la cuota.
+func RateLimited(retryAfter string) Reply {
+	return Reply{Status: http.StatusTooManyRequests, RetryAfter: retryAfter}
+}
+
+// Sequence devuelve un Handler que responde con replies en orden y repite la
+// última cuando se agotan, útil para simular fallos seguidos de un éxito.
+func Sequence(replies ...Reply) Handler {
+	var mu sync.Mutex
+	next := 0
+	return func(Request) Reply {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(replies) == 0 {
+			return Reply{}
+		}
+		reply := replies[min(next, len(replies)-1)]
+		next++
+		return reply
+	}
+}
+
+// ClientOptions devuelve las opciones para que genai.NewClient (o
+// geminifix.NewClient) hable con este servidor en lugar de con Google.
+func (s *Server) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(s.URL),
+		option.WithAPIKey("fake-api-key"),
+	}
+}
+
+// Requests devuelve una copia de las peticiones recibidas hasta el momento.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Close detiene el servidor.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// generateContentRequest es el subconjunto del JSON de la petición que nos interesa.
+type generateContentRequest struct {
+	Model    string `json:"model"`
+	Contents []struct {
+		Parts []struct {
+			Text       string `json:"text"`
+			InlineData *struct {
+				MIMEType string `json:"mimeType"`
+				Data     string `json:"data"`
+			} `json:"inlineData"`
+		} `json:"parts"`
+	} `json:"contents"`
+	GenerationConfig struct {
+		ResponseMIMEType string `json:"responseMimeType"`
+	} `json:"generationConfig"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, ":generateContent") {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("ruta no soportada: %s %s", r.Method, r.URL.Path))
+		return
+	}
+
+	var body generateContentRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("JSON inválido: %v", err))
+		return
+	}
+
+	req := Request{
+		Model:            strings.TrimPrefix(body.Model, "models/"),
+		ResponseMIMEType: body.GenerationConfig.ResponseMIMEType,
+	}
+	for _, content := range body.Contents {
+		for _, part := range content.Parts {
+			if part.InlineData != nil {
+				data, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("inlineData inválido: %v", err))
+					return
+				}
+				req.Images = append(req.Images, Image{MIMEType: part.InlineData.MIMEType, Data: data})
+				continue
+			}
+			req.Texts = append(req.Texts, part.Text)
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	reply := s.handler(req)
+	if reply.Delay > 0 {
+		select {
+		case <-time.After(reply.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if reply.RetryAfter != "" {
+		w.Header().Set("Retry-After", reply.RetryAfter)
+	}
+	switch {
+	case reply.Status != 0 && reply.Status != http.StatusOK:
+		writeError(w, reply.Status, http.StatusText(reply.Status))
+	case reply.Raw != "":
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, reply.Raw)
+	default:
+		writeText(w, reply.Text)
+	}
+}
+
+// writeText escribe una respuesta de generateContent con un único candidato.
+func writeText(w http.ResponseWriter, text string) {
+	resp := map[string]any{
+		"candidates": []any{
+			map[string]any{
+				"content": map[string]any{
+					"role":  "model",
+					"parts": []any{map[string]any{"text": text}},
+				},
+				"finishReason": 1, // STOP
+			},
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeError escribe un error con el formato que usan las APIs de Google.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": message,
+			"status":  http.StatusText(status),
+		},
+	})
+}
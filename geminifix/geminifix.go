@@ -12,19 +12,44 @@ import (
 	"google.golang.org/api/option"
 )
 
-func NewClient(ctx context.Context) (*genai.Client, error) {
+// NewClient crea un cliente de Gemini con la clave de GEMINI_API_KEY. Las opciones
+// adicionales se aplican después de la clave, lo que permite, por ejemplo,
+// apuntar el cliente a un servidor local con option.WithEndpoint.
+func NewClient(ctx context.Context, opts ...option.ClientOption) (*genai.Client, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("la variable de entorno GEMINI_API_KEY no está configurada")
 	}
 
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	opts = append([]option.ClientOption{option.WithAPIKey(apiKey)}, opts...)
+	client, err := genai.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error al crear el cliente de Gemini: %w", err)
 	}
 	return client, nil
 }
 
+// DefaultCorrectionModel es el modelo usado para corregir textos si no se indica otro.
+const DefaultCorrectionModel = "gemini-2.0-flash"
+
+// CorrectionModel es el modelo que usa CorrectTextBatch. main.go lo cambia con el flag -gemini-model.
+var CorrectionModel = DefaultCorrectionModel
+
+// legacyModelPrefixes son los modelos que no admiten ResponseSchema y, por tanto,
+// siguen usando el formato de texto "LÍNEA N: texto".
+var legacyModelPrefixes = []string{"gemini-1.0-", "gemini-pro"}
+
+// SupportsResponseSchema indica si el modelo admite salida JSON con esquema.
+func SupportsResponseSchema(model string) bool {
+	model = strings.TrimPrefix(model, "models/")
+	for _, prefix := range legacyModelPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
 // buildBatchAnimePrompt construye un prompt para corregir un lote de subtítulos.
 func buildBatchAnimePrompt(texts []string) string {
 	var numberedTexts strings.Builder
@@ -48,6 +73,8 @@ Limpies cualquier texto suelto sin sentido, caracteres sobrantes o frases que no
 
 Mantengas la coherencia de estilo como si fueran subtítulos profesionales de anime, breves y naturales.
 
+Conserves las etiquetas de formato <i> y <b> alrededor del mismo texto.
+
 Responde con el archivo SRT corregido respetando el mismo orden de líneas y marcas de tiempo.
 
 Dame solo la respuesta sin explicaciones ni nada mas.
@@ -56,26 +83,48 @@ Dame solo la respuesta sin explicaciones ni nada mas.
 `, numberedTexts.String())
 }
 
-// CorrectTextBatch utiliza Gemini para corregir un lote de textos.
+// CorrectTextBatch utiliza Gemini para corregir un lote de textos con CorrectionModel.
 func CorrectTextBatch(ctx context.Context, client *genai.Client, batchToCorrect []string) ([]string, error) {
+	return CorrectTextBatchWithModel(ctx, client, CorrectionModel, batchToCorrect)
+}
+
+// CorrectTextBatchWithModel corrige un lote con el modelo indicado. Los modelos que
+// admiten esquema de respuesta devuelven JSON que se valida estrictamente; los
+// modelos antiguos usan el formato de texto "LÍNEA N: texto".
+func CorrectTextBatchWithModel(ctx context.Context, client *genai.Client, modelName string, batchToCorrect []string) ([]string, error) {
 	if len(batchToCorrect) == 0 {
 		return []string{}, nil
 	}
+	if SupportsResponseSchema(modelName) {
+		return correctTextBatchJSON(ctx, client, modelName, batchToCorrect)
+	}
+	return correctTextBatchLegacy(ctx, client, modelName, batchToCorrect)
+}
 
-	model := client.GenerativeModel("gemini-2.0-flash")
+// responseText extrae el texto del primer candidato de una respuesta de Gemini.
+func responseText(resp *genai.GenerateContentResponse) (string, error) {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("Gemini no devolvió candidatos")
+	}
+	rawResponse, ok := resp.Candidates[0].Content.Parts[0].(genai.Text)
+	if !ok {
+		return "", fmt.Errorf("la respuesta de Gemini no es de tipo texto")
+	}
+	return string(rawResponse), nil
+}
+
+// correctTextBatchLegacy pide la corrección en formato "LÍNEA N: texto" y la parsea línea a línea.
+func correctTextBatchLegacy(ctx context.Context, client *genai.Client, modelName string, batchToCorrect []string) ([]string, error) {
+	model := client.GenerativeModel(modelName)
 	prompt := buildBatchAnimePrompt(batchToCorrect)
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
 		return nil, fmt.Errorf("error al generar contenido con Gemini: %w", err)
 	}
 
-	// ... (código para extraer el texto de la respuesta de Gemini) ...
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("Gemini no devolvió candidatos")
-	}
-	rawResponse, ok := resp.Candidates[0].Content.Parts[0].(genai.Text)
-	if !ok {
-		return nil, fmt.Errorf("la respuesta de Gemini no es de tipo texto")
+	rawResponse, err := responseText(resp)
+	if err != nil {
+		return nil, err
 	}
 
 	// --- PARSEAR LA RESPUESTA DE GEMINI ---
@@ -0,0 +1,165 @@
+package geminifix
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/yoshi70001/googleDocsOCR/geminifix/geminifake"
+)
+
+var update = flag.Bool("update", false, "reescribe los archivos .golden de testdata")
+
+// goldenBatch es el lote de entrada común a todos los casos golden.
+var goldenBatch = []string{"hola mundo", "como estas", "adios sasuke kun"}
+
+// newTestClient arranca un servidor falso de Gemini y devuelve un cliente conectado a él.
+func newTestClient(t *testing.T, handler geminifake.Handler) (*genai.Client, *geminifake.Server) {
+	t.Helper()
+	fake := geminifake.NewServer(handler)
+	t.Cleanup(fake.Close)
+
+	t.Setenv("GEMINI_API_KEY", "test")
+	client, err := NewClient(context.Background(), fake.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, fake
+}
+
+// formatBatch serializa un lote de forma inequívoca, una entrada por línea.
+func formatBatch(batch []string) string {
+	var b strings.Builder
+	for i, text := range batch {
+		fmt.Fprintf(&b, "%d: %q\n", i, text)
+	}
+	return b.String()
+}
+
+// legacyTestModel es un modelo sin soporte de ResponseSchema, para forzar el
+// parser de texto "LÍNEA N: texto".
+const legacyTestModel = "gemini-1.0-pro"
+
+// TestCorrectTextBatchGolden envía cada testdata/correct/<modo>/*.response como
+// respuesta del modelo y compara el lote parseado (o el error) con su .golden.
+func TestCorrectTextBatchGolden(t *testing.T) {
+	modes := []struct {
+		dir          string
+		model        string
+		wantMIMEType string
+	}{
+		{"json", DefaultCorrectionModel, "application/json"},
+		{"legacy", legacyTestModel, ""},
+	}
+	for _, mode := range modes {
+		responses, err := filepath.Glob(filepath.Join("testdata", "correct", mode.dir, "*.response"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(responses) == 0 {
+			t.Fatalf("no hay casos en testdata/correct/%s", mode.dir)
+		}
+
+		for _, responsePath := range responses {
+			name := mode.dir + "/" + strings.TrimSuffix(filepath.Base(responsePath), ".response")
+			t.Run(name, func(t *testing.T) {
+				response, err := os.ReadFile(responsePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				client, fake := newTestClient(t, geminifake.Text(string(response)))
+
+				var got string
+				corrected, err := CorrectTextBatchWithModel(context.Background(), client, mode.model, goldenBatch)
+				if err != nil {
+					got = fmt.Sprintf("error: %v\n", err)
+				} else {
+					got = formatBatch(corrected)
+				}
+
+				goldenPath := strings.TrimSuffix(responsePath, ".response") + ".golden"
+				if *update {
+					if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(goldenPath)
+				if err != nil {
+					t.Fatalf("%v (ejecuta go test -update para generarlo)", err)
+				}
+				if got != string(want) {
+					t.Errorf("resultado:\n%s\nse esperaba:\n%s", got, want)
+				}
+
+				reqs := fake.Requests()
+				if len(reqs) != 1 || len(reqs[0].Texts) != 1 {
+					t.Fatalf("peticiones = %+v", reqs)
+				}
+				if reqs[0].Model != mode.model {
+					t.Errorf("modelo = %q, se esperaba %q", reqs[0].Model, mode.model)
+				}
+				if reqs[0].ResponseMIMEType != mode.wantMIMEType {
+					t.Errorf("responseMimeType = %q, se esperaba %q", reqs[0].ResponseMIMEType, mode.wantMIMEType)
+				}
+				for _, text := range goldenBatch {
+					if !strings.Contains(reqs[0].Texts[0], text) {
+						t.Errorf("el prompt no contiene %q", text)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSupportsResponseSchema(t *testing.T) {
+	tests := map[string]bool{
+		"gemini-2.0-flash":          true,
+		"models/gemini-1.5-pro":     true,
+		"gemini-1.0-pro":            false,
+		"gemini-pro":                false,
+		"models/gemini-1.0-pro-001": false,
+	}
+	for model, want := range tests {
+		if got := SupportsResponseSchema(model); got != want {
+			t.Errorf("SupportsResponseSchema(%q) = %v, se esperaba %v", model, got, want)
+		}
+	}
+}
+
+func TestCorrectTextBatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply geminifake.Reply
+	}{
+		{"rate_limited", geminifake.RateLimited("1")},
+		{"server_error", geminifake.Reply{Status: 500}},
+		{"malformed_json", geminifake.Reply{Raw: `{"candidates": [`}},
+		{"no_candidates", geminifake.Reply{Raw: `{"candidates": []}`}},
+		{"timeout", geminifake.Reply{Text: "LÍNEA 0: tarde", Delay: 5 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, geminifake.Sequence(tt.reply))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			if got, err := CorrectTextBatch(ctx, client, goldenBatch); err == nil {
+				t.Fatalf("se esperaba un error, se obtuvo %q", got)
+			}
+		})
+	}
+}
+
+func TestCorrectTextBatchEmpty(t *testing.T) {
+	got, err := CorrectTextBatch(context.Background(), nil, nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("CorrectTextBatch(nil) = %q, %v", got, err)
+	}
+}
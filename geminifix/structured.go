@@ -0,0 +1,140 @@
+// geminifix/structured.go
+package geminifix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// correctedLine es cada elemento del array JSON que devuelve Gemini.
+type correctedLine struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+// correctionSchema obliga al modelo a responder con un array de {index, text}.
+var correctionSchema = &genai.Schema{
+	Type: genai.TypeArray,
+	Items: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"index": {Type: genai.TypeInteger, Description: "Índice de la línea de entrada, empezando en 0."},
+			"text":  {Type: genai.TypeString, Description: "Texto corregido. Puede contener saltos de línea."},
+		},
+		Required: []string{"index", "text"},
+	},
+}
+
+// buildBatchJSONPrompt construye el prompt para la corrección con salida estructurada.
+func buildBatchJSONPrompt(texts []string) (string, error) {
+	input := make([]correctedLine, len(texts))
+	for i, text := range texts {
+		input[i] = correctedLine{Index: i, Text: text}
+	}
+	inputJSON, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("no se pudo serializar el lote: %w", err)
+	}
+
+	return fmt.Sprintf(`
+Te proporcionaré un array JSON con las líneas de un subtítulo de anime obtenidas por OCR. Tienen texto mezclado en japonés y español, además de frases sin sentido o errores de transcripción.
+
+Quiero que:
+
+Corrijas la gramática y ortografía del texto en español.
+
+Para las partes en japonés no traducidas (o nombres japoneses), si no hay traducción disponible, déjalas tal cual.
+
+Limpies cualquier texto suelto sin sentido, caracteres sobrantes o frases que no aportan nada (por ejemplo números aleatorios, palabras aisladas que no se entienden).
+
+Mantengas la coherencia de estilo como si fueran subtítulos profesionales de anime, breves y naturales.
+
+Conserves las etiquetas de formato <i> y <b> alrededor del mismo texto.
+
+Responde con un array JSON con exactamente un objeto {"index", "text"} por cada línea de entrada, con el mismo índice. Si una línea queda vacía tras limpiarla, devuelve "text" vacío.
+
+%s
+`, inputJSON), nil
+}
+
+// correctTextBatchJSON pide la corrección como JSON con esquema y la valida.
+func correctTextBatchJSON(ctx context.Context, client *genai.Client, modelName string, batchToCorrect []string) ([]string, error) {
+	model := client.GenerativeModel(modelName)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = correctionSchema
+
+	prompt, err := buildBatchJSONPrompt(batchToCorrect)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("error al generar contenido con Gemini: %w", err)
+	}
+
+	rawResponse, err := responseText(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONCorrections(rawResponse, len(batchToCorrect))
+}
+
+// parseJSONCorrections valida que la respuesta sea un array con exactamente una
+// entrada por cada índice de 0 a n-1 y devuelve los textos en orden. Cualquier
+// desviación es un error, para que el lote se reintente en lugar de desalinear
+// los subtítulos.
+func parseJSONCorrections(rawResponse string, n int) ([]string, error) {
+	dec := json.NewDecoder(strings.NewReader(stripCodeFence(rawResponse)))
+	dec.DisallowUnknownFields()
+
+	// Punteros para distinguir un campo ausente de un valor cero.
+	var lines []struct {
+		Index *int    `json:"index"`
+		Text  *string `json:"text"`
+	}
+	if err := dec.Decode(&lines); err != nil {
+		return nil, fmt.Errorf("la respuesta de Gemini no es un JSON válido: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("la respuesta de Gemini contiene datos después del JSON")
+	}
+	if len(lines) != n {
+		return nil, fmt.Errorf("Gemini devolvió %d líneas, pero se esperaban %d", len(lines), n)
+	}
+
+	correctedBatch := make([]string, n)
+	seen := make([]bool, n)
+	for i, line := range lines {
+		if line.Index == nil || line.Text == nil {
+			return nil, fmt.Errorf("el elemento %d de la respuesta de Gemini no tiene 'index' y 'text'", i)
+		}
+		index := *line.Index
+		if index < 0 || index >= n {
+			return nil, fmt.Errorf("Gemini devolvió el índice %d, fuera del rango [0, %d)", index, n)
+		}
+		if seen[index] {
+			return nil, fmt.Errorf("Gemini devolvió el índice %d más de una vez", index)
+		}
+		seen[index] = true
+		correctedBatch[index] = strings.TrimSpace(*line.Text)
+	}
+	return correctedBatch, nil
+}
+
+// stripCodeFence quita un bloque ```json ... ``` que envuelva la respuesta, por
+// si el modelo lo añade pese a ResponseMIMEType.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.TrimSpace(s)
+	return strings.TrimSuffix(s, "```")
+}
@@ -0,0 +1,99 @@
+// geminifix/vision.go
+package geminifix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/yoshi70001/googleDocsOCR/ocr"
+)
+
+// VisionEngineName es el nombre con el que se selecciona este motor en el flag -engine.
+const VisionEngineName = "gemini"
+
+// DefaultVisionModel es el modelo multimodal usado si no se indica otro.
+const DefaultVisionModel = "gemini-2.0-flash"
+
+// visionPrompt es la instrucción que acompaña a cada fotograma.
+const visionPrompt = `La imagen es un fotograma recortado de un anime que contiene un subtítulo.
+Transcribe exactamente el texto del subtítulo, respetando los saltos de línea.
+Corrige solo los errores evidentes de ortografía en español; los nombres y palabras en japonés déjalos tal cual.
+Si la imagen no contiene texto, responde con una cadena vacía.
+Dame solo el texto del subtítulo, sin explicaciones, comillas ni formato adicional.`
+
+// VisionEngine implementa ocr.Engine enviando cada fotograma a un modelo
+// multimodal de Gemini, de modo que la corrección se hace viendo la imagen.
+type VisionEngine struct {
+	client *genai.Client
+	model  string
+}
+
+// NewVisionEngine crea un motor que usa el cliente (ver NewClient) y el modelo
+// indicados. Si model está vacío se usa DefaultVisionModel.
+func NewVisionEngine(client *genai.Client, model string) *VisionEngine {
+	if model == "" {
+		model = DefaultVisionModel
+	}
+	return &VisionEngine{client: client, model: model}
+}
+
+// Name devuelve el identificador del motor.
+func (e *VisionEngine) Name() string {
+	return VisionEngineName
+}
+
+// AcceptsFormat indica si Gemini acepta imágenes en ese formato (los de
+// imageFormat).
+func (e *VisionEngine) AcceptsFormat(format string) bool {
+	return format == "png" || format == "jpeg" || format == "webp"
+}
+
+// Recognize envía la imagen a Gemini y devuelve el texto del subtítulo.
+func (e *VisionEngine) Recognize(ctx context.Context, image []byte, filename string) (*ocr.Result, error) {
+	format, err := imageFormat(image)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	log.Printf("    - Enviando fotograma a Gemini (%s)...", e.model)
+	model := e.client.GenerativeModel(e.model)
+	resp, err := model.GenerateContent(ctx, genai.ImageData(format, image), genai.Text(visionPrompt))
+	if err != nil {
+		return nil, fmt.Errorf("error al generar contenido con Gemini: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil, fmt.Errorf("Gemini no devolvió candidatos")
+	}
+
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text.WriteString(string(t))
+		}
+	}
+
+	return &ocr.Result{
+		Text: strings.TrimSpace(text.String()),
+		Metadata: map[string]string{
+			"model": e.model,
+		},
+	}, nil
+}
+
+// imageFormat detecta el formato de la imagen tal y como lo espera genai.ImageData.
+func imageFormat(image []byte) (string, error) {
+	switch contentType := http.DetectContentType(image); contentType {
+	case "image/png":
+		return "png", nil
+	case "image/jpeg":
+		return "jpeg", nil
+	case "image/webp":
+		return "webp", nil
+	default:
+		return "", fmt.Errorf("formato de imagen no soportado por Gemini: %s", contentType)
+	}
+}
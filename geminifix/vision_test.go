@@ -0,0 +1,52 @@
+package geminifix
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/yoshi70001/googleDocsOCR/geminifix/geminifake"
+)
+
+func TestVisionEngineRecognize(t *testing.T) {
+	client, fake := newTestClient(t, geminifake.Text("¿Qué estás haciendo, Naruto?\n"))
+	ctx := context.Background()
+
+	var frame bytes.Buffer
+	if err := png.Encode(&frame, image.NewRGBA(image.Rect(0, 0, 8, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewVisionEngine(client, "")
+	result, err := engine.Recognize(ctx, frame.Bytes(), "0_00_01_000__0_00_02_000.png")
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+	if got := strings.TrimSpace(result.Text); got != "¿Qué estás haciendo, Naruto?" {
+		t.Errorf("texto = %q", got)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("se esperaba 1 petición, hubo %d", len(reqs))
+	}
+	if reqs[0].Model != DefaultVisionModel {
+		t.Errorf("modelo = %q, se esperaba %q", reqs[0].Model, DefaultVisionModel)
+	}
+	if len(reqs[0].Images) != 1 || reqs[0].Images[0].MIMEType != "image/png" {
+		t.Fatalf("imágenes enviadas = %+v", reqs[0].Images)
+	}
+	if !bytes.Equal(reqs[0].Images[0].Data, frame.Bytes()) {
+		t.Errorf("los bytes de la imagen enviada no coinciden")
+	}
+}
+
+func TestVisionEngineRejectsUnknownFormat(t *testing.T) {
+	engine := NewVisionEngine(nil, "")
+	if _, err := engine.Recognize(context.Background(), []byte("no es una imagen"), "x.txt"); err == nil {
+		t.Fatal("se esperaba un error para un formato desconocido")
+	}
+}
@@ -0,0 +1,15 @@
+// Package imagecodecs registra en image.Decode los decodificadores de todos
+// los formatos de ocr.ImageExtensions: GIF, JPEG, PNG, BMP, TIFF y WebP. Lo
+// importan en blanco los paquetes que decodifican imágenes, para que no
+// dependan de lo que haya importado el programa.
+package imagecodecs
+
+import (
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
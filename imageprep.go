@@ -0,0 +1,81 @@
+// imageprep.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yoshi70001/googleDocsOCR/preprocess"
+)
+
+// prepFlags agrupa los flags del preprocesado de imágenes antes del OCR.
+type prepFlags struct {
+	opts preprocess.Options
+	crop string
+	dir  string
+}
+
+// register declara los flags en fs.
+func (p *prepFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.crop, "crop", "", "Recortar las imágenes a la región \"x,y,ancho,alto\" en píxeles antes del OCR")
+	fs.BoolVar(&p.opts.Grayscale, "grayscale", false, "Pasar las imágenes a escala de grises antes del OCR")
+	fs.IntVar(&p.opts.Threshold, "threshold", 0, "Binarizar las imágenes: luminancia >= umbral pasa a blanco y el resto a negro (1-255, 0 = desactivado)")
+	fs.BoolVar(&p.opts.Invert, "invert", false, "Invertir los colores (texto claro sobre fondo oscuro pasa a oscuro sobre claro)")
+	fs.Float64Var(&p.opts.Scale, "upscale", 1, "Factor de ampliación de las imágenes antes del OCR (1 = sin cambio, máximo 8)")
+	fs.IntVar(&p.opts.Padding, "padding", 0, "Margen en píxeles, del color del fondo, añadido alrededor de las imágenes")
+	fs.StringVar(&p.dir, "preprocess-dir", "", "Carpeta donde guardar las imágenes preprocesadas para revisarlas (por defecto, una carpeta temporal que se borra al terminar)")
+}
+
+// options valida los flags ya parseados y devuelve las opciones resultantes.
+func (p *prepFlags) options() (preprocess.Options, error) {
+	opts := p.opts
+	crop, err := preprocess.ParseCrop(p.crop)
+	if err != nil {
+		return opts, err
+	}
+	opts.Crop = crop
+	if err := opts.Validate(); err != nil {
+		return opts, fmt.Errorf("opciones de preprocesado inválidas: %v", err)
+	}
+	return opts, nil
+}
+
+// imagePrep prepara cada imagen antes de enviarla al motor de OCR.
+type imagePrep struct {
+	opts preprocess.Options
+	dir  string
+}
+
+// newImagePrep crea la carpeta de trabajo del preprocesado. Devuelve nil si no
+// hay ningún paso activo; la función cleanup borra la carpeta si es temporal.
+func newImagePrep(opts preprocess.Options, dir string) (prep *imagePrep, cleanup func(), err error) {
+	cleanup = func() {}
+	if !opts.Enabled() {
+		return nil, cleanup, nil
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, cleanup, fmt.Errorf("no se pudo crear la carpeta de preprocesado: %v", err)
+		}
+	} else {
+		if dir, err = os.MkdirTemp("", "googleDocsOCR-prep-"); err != nil {
+			return nil, cleanup, fmt.Errorf("no se pudo crear la carpeta temporal de preprocesado: %v", err)
+		}
+		tempDir := dir
+		cleanup = func() { os.RemoveAll(tempDir) }
+	}
+	log.Printf("✓ Preprocesado de imágenes activo (carpeta: %s).", dir)
+	return &imagePrep{opts: opts, dir: dir}, cleanup, nil
+}
+
+// prepare devuelve la ruta de la imagen que se enviará al OCR: la original si
+// no hay preprocesado, o la procesada en la carpeta de trabajo. El nombre base
+// se conserva para que el documento y los logs coincidan con la imagen.
+func (p *imagePrep) prepare(imagePath string) (string, error) {
+	if p == nil {
+		return imagePath, nil
+	}
+	return preprocess.ProcessFile(imagePath, p.dir, p.opts)
+}
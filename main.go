@@ -4,7 +4,9 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,14 +17,18 @@ import (
 	"github.com/google/generative-ai-go/genai"
 	"github.com/yoshi70001/googleDocsOCR/gdrive"
 	"github.com/yoshi70001/googleDocsOCR/geminifix"
+	"github.com/yoshi70001/googleDocsOCR/manifest"
+	"github.com/yoshi70001/googleDocsOCR/ocr"
 	"github.com/yoshi70001/googleDocsOCR/srtbuilder"
+	"github.com/yoshi70001/googleDocsOCR/tesseract"
+	"golang.org/x/time/rate"
 )
 
 const (
 	imagesFolder    = "RGBImages"
 	textsFolder     = "TXTImages"
 	driveTempFolder = "Temp_OCR_Go"
-	outputSrtFile   = "subtitulo.srt"
+	outputBaseName  = "subtitulo"
 )
 
 var version = "development"
@@ -30,32 +36,108 @@ var version = "development"
 func main() {
 	log.Printf("googleDocsOCR version %s", version)
 
-       useGemini := flag.Bool("use-gemini", false, "Activar corrección de texto con Gemini")
-       useLocation := flag.Bool("use-location", false, "Usar el nombre de la carpeta actual para el archivo SRT")
-       flag.Parse()
-
-       ctx := context.Background()
-
-       // Inicializar cliente de Gemini solo si se solicita
-       var geminiClient *genai.Client
-       if *useGemini {
-	       if os.Getenv("GEMINI_API_KEY") != "" {
-		       var err error
-		       geminiClient, err = geminifix.NewClient(ctx)
-		       if err != nil {
-			       log.Fatalf("Fallo al inicializar el cliente de Gemini: %v", err)
-		       }
-		       defer geminiClient.Close()
-		       log.Println("✓ Cliente de Gemini inicializado.")
-	       } else {
-		       log.Println("[!] ADVERTENCIA: No se encontró la GEMINI_API_KEY. Se procederá sin corrección de IA.")
-	       }
-       } else {
-	       log.Println("[!] Gemini desactivado. No se realizará corrección de IA.")
-       }
-
-       // --- PASO 1: PROCESAMIENTO OCR ---
-       log.Println("===== INICIANDO PASO 1: EXTRACCIÓN DE TEXTO (OCR) =====")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "cleanup":
+			runCleanup(os.Args[2:])
+			return
+		case "retime":
+			runRetime(os.Args[2:])
+			return
+		case "fix":
+			runFix(os.Args[2:])
+			return
+		}
+	}
+
+	useGemini := flag.Bool("use-gemini", false, "Activar corrección de texto con Gemini")
+	geminiModel := flag.String("gemini-model", geminifix.DefaultCorrectionModel, "Modelo de Gemini para la corrección de texto (los modelos antiguos usan el formato LÍNEA N)")
+	manifestPath := flag.String("manifest", manifest.DefaultFile, "Archivo JSON donde se guarda el progreso para reanudar ejecuciones interrumpidas")
+	useLocation := flag.Bool("use-location", false, "Usar el nombre de la carpeta actual para el archivo de subtítulos")
+	var output outputFlags
+	output.register(flag.CommandLine)
+	cleanRules := flag.String("clean-rules", strings.Join(srtbuilder.DefaultCleanRules, ","), "Reglas de limpieza del texto de OCR, en orden y separadas por comas (disponibles: "+strings.Join(srtbuilder.CleanRuleNames(), ", ")+")")
+	var filenameOpts srtbuilder.FilenameOptions
+	flag.StringVar(&filenameOpts.Preset, "filename-preset", srtbuilder.PresetVideoSubFinder, "Formato de los nombres de las imágenes: "+strings.Join(srtbuilder.Presets(), ", "))
+	flag.StringVar(&filenameOpts.Regex, "filename-regex", "", "Expresión regular con los grupos (?P<start>...) y (?P<end>...) para leer los tiempos del nombre (sustituye a -filename-preset)")
+	flag.StringVar(&filenameOpts.FrameRegex, "frame-regex", "", "Expresión regular con (?P<start>...) y opcionalmente (?P<end>...) como números de fotograma (requiere -frame-fps)")
+	flag.Float64Var(&filenameOpts.FPS, "frame-fps", 0, "Fps del vídeo para convertir números de fotograma en tiempos (con -frame-regex)")
+	var engineCfg engineConfig
+	flag.StringVar(&engineCfg.name, "engine", gdrive.EngineName, "Motor de OCR a utilizar (gdrive, tesseract, gemini)")
+	flag.StringVar(&engineCfg.tesseractBin, "tesseract-bin", "tesseract", "Ruta al ejecutable de tesseract (motor tesseract)")
+	flag.StringVar(&engineCfg.tesseractLang, "tesseract-lang", "jpn+spa", "Paquetes de idioma de tesseract, separados por '+' (motor tesseract)")
+	workers := flag.Int("workers", 5, "Número de imágenes que se procesan a la vez")
+	flag.Float64Var(&engineCfg.driveRPS, "drive-rps", 0, "Máximo de peticiones por segundo a Drive, compartido entre todos los workers (0 = sin límite)")
+	flag.IntVar(&engineCfg.driveBurst, "drive-burst", 0, "Peticiones a Drive que se permiten de golpe por encima de -drive-rps (0 = automático)")
+	flag.BoolVar(&engineCfg.driveHTML, "drive-html", false, "Exportar los documentos como HTML para conservar cursiva y negrita (<i>, <b>) (motor gdrive)")
+	flag.DurationVar(&engineCfg.sweepOlderThan, "sweep-older-than", defaultSweepAge, "Antes de empezar, borrar los documentos de la carpeta temporal de Drive más antiguos que esto (0 para desactivar)")
+	flag.StringVar(&engineCfg.visionModel, "gemini-vision-model", geminifix.DefaultVisionModel, "Modelo multimodal de Gemini (motor gemini)")
+	stitchSize := flag.Int("stitch", 0, "Número de imágenes que se apilan en una sola petición de OCR, separadas por marcadores (0 o 1 = una petición por imagen)")
+	dedup := flag.Bool("dedup", false, "Reconocer solo una de cada serie de imágenes consecutivas casi idénticas y copiar su texto a las demás")
+	dedupThreshold := flag.Float64("dedup-threshold", 0.05, "Fracción de bits distintos del hash perceptual (0-1) hasta la que dos imágenes se consideran iguales (con -dedup)")
+	var prep prepFlags
+	prep.register(flag.CommandLine)
+	flag.Parse()
+
+	// Validar ahora y no después de todo el OCR.
+	outputOpts, err := output.options()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputOpts.FilenameParser, err = filenameOpts.Parser(); err != nil {
+		log.Fatal(err)
+	}
+	var ruleNames []string
+	if *cleanRules != "" {
+		ruleNames = strings.Split(*cleanRules, ",")
+	}
+	if outputOpts.Cleaner, err = srtbuilder.NewCleaner(ruleNames); err != nil {
+		log.Fatal(err)
+	}
+	if *workers < 1 {
+		log.Fatalf("-workers debe ser al menos 1 (valor: %d)", *workers)
+	}
+	if engineCfg.driveRPS < 0 {
+		log.Fatalf("-drive-rps no puede ser negativo (valor: %g)", engineCfg.driveRPS)
+	}
+	if engineCfg.driveBurst < 0 {
+		log.Fatalf("-drive-burst no puede ser negativo (valor: %d)", engineCfg.driveBurst)
+	}
+	if *stitchSize < 0 {
+		log.Fatalf("-stitch no puede ser negativo (valor: %d)", *stitchSize)
+	}
+	if *dedupThreshold < 0 || *dedupThreshold > 1 {
+		log.Fatalf("-dedup-threshold debe estar entre 0 y 1 (valor: %g)", *dedupThreshold)
+	}
+	prepOpts, err := prep.options()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	geminifix.CorrectionModel = *geminiModel
+
+	// Inicializar cliente de Gemini solo si se solicita
+	geminiClient := newCorrectionClient(ctx, *useGemini)
+	if geminiClient != nil {
+		defer geminiClient.Close()
+	}
+
+	// El motor de visión de Gemini necesita un cliente aunque no se pida la corrección.
+	if engineCfg.name == geminifix.VisionEngineName {
+		engineCfg.geminiClient = geminiClient
+		if engineCfg.geminiClient == nil {
+			client, err := geminifix.NewClient(ctx)
+			if err != nil {
+				log.Fatalf("Fallo al inicializar el cliente de Gemini para OCR: %v", err)
+			}
+			defer client.Close()
+			engineCfg.geminiClient = client
+		}
+	}
+
+	// --- PASO 1: PROCESAMIENTO OCR ---
+	log.Println("===== INICIANDO PASO 1: EXTRACCIÓN DE TEXTO (OCR) =====")
 
 	// Crear carpetas locales si no existen
 	if _, err := os.Stat(imagesFolder); os.IsNotExist(err) {
@@ -66,16 +148,26 @@ func main() {
 		os.Mkdir(textsFolder, 0755)
 	}
 
-	srv, err := gdrive.AuthenticateAndGetService()
+	engine, err := newOCREngine(engineCfg)
+	if err != nil {
+		log.Fatalf("No se pudo inicializar el motor de OCR: %v", err)
+	}
+	log.Printf("✓ Motor de OCR: %s", engine.Name())
+
+	runManifest, err := manifest.Load(*manifestPath)
 	if err != nil {
-		log.Fatalf("Fallo en la autenticación: %v", err)
+		log.Fatalf("No se pudo cargar el manifiesto: %v", err)
+	}
+	log.Printf("✓ Manifiesto de progreso: %s", runManifest.Path())
+	if driveEngine, ok := engine.(*gdrive.Engine); ok {
+		deleteInterruptedDocs(ctx, driveEngine, runManifest)
 	}
-	log.Println("✓ Autenticación exitosa.")
 
-	driveFolderID, err := gdrive.GetOrCreateFolder(srv, driveTempFolder)
+	imgPrep, cleanupPrep, err := newImagePrep(prepOpts, prep.dir)
 	if err != nil {
-		log.Fatalf("No se pudo obtener/crear la carpeta de Drive: %v", err)
+		log.Fatal(err)
 	}
+	defer cleanupPrep()
 
 	// Leer y ordenar las imágenes a procesar
 	files, err := os.ReadDir(imagesFolder)
@@ -83,74 +175,363 @@ func main() {
 		log.Fatalf("No se pudo leer la carpeta de imágenes: %v", err)
 	}
 
-	var imagePaths []string
+	var imagePaths, skipped []string
 	for _, file := range files {
-		ext := strings.ToLower(filepath.Ext(file.Name()))
-		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" {
+		if file.IsDir() {
+			continue
+		}
+		if ocr.IsImageFile(file.Name()) {
 			imagePaths = append(imagePaths, file.Name())
+		} else {
+			skipped = append(skipped, file.Name())
 		}
 	}
 	sort.Strings(imagePaths)
+	logSkippedFiles(skipped)
+	imagePaths, collisions := dropTextCollisions(imagePaths)
+	logTextCollisions(collisions)
 
 	if len(imagePaths) == 0 {
 		log.Println("No se encontraron imágenes para procesar.")
 	} else {
-		log.Printf("Se procesarán %d imágenes. Iniciando goroutines...", len(imagePaths))
+		log.Printf("Se procesarán %d imágenes con %d workers. Iniciando goroutines...", len(imagePaths), *workers)
 		startTime := time.Now()
 
 		// --- CONTROL DE CONCURRENCIA ---
-		const maxConcurrency = 5 // Limita a 5 subidas simultáneas
-		semaphore := make(chan struct{}, maxConcurrency)
+		semaphore := make(chan struct{}, *workers) // Limita las subidas simultáneas
 		var wg sync.WaitGroup
 		// -------------------------------
 
-		for _, imgFilename := range imagePaths {
+		// Imágenes que fallaron tras agotar los reintentos, para el resumen final.
+		var failedMu sync.Mutex
+		failed := make(map[string]error)
+
+		// Las comprobaciones del manifiesto se hacen antes de repartir el
+		// trabajo para poder agrupar solo las imágenes pendientes.
+		var jobs []*imageJob
+		for _, filename := range imagePaths {
+			job, err := newImageJob(runManifest, engine, filename)
+			if err != nil {
+				log.Printf("ERROR procesando %s: %v", filename, err)
+				failed[filename] = err
+				continue
+			}
+			if job != nil {
+				jobs = append(jobs, job)
+			}
+		}
+
+		var clusters []dedupCluster
+		if *dedup {
+			jobs, clusters = dedupJobs(jobs, *dedupThreshold)
+		}
+
+		var stats ocrStats
+		for _, group := range groupJobs(jobs, *stitchSize) {
 			wg.Add(1)
 			semaphore <- struct{}{} // Adquiere un "slot"
 
-			go func(filename string) {
+			go func(group []*imageJob) {
 				defer wg.Done()
 				defer func() { <-semaphore }() // Libera el "slot" al final
 
-				fullImagePath := filepath.Join(imagesFolder, filename)
-				textFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".txt"
-				fullTextPath := filepath.Join(textsFolder, textFilename)
-
-				if _, err := os.Stat(fullTextPath); err == nil {
-					log.Printf("[SKIP] El archivo de texto para '%s' ya existe. Saltando OCR.", filename)
-					return
-				}
-
-				err := gdrive.ProcessImage(srv, fullImagePath, fullTextPath, driveFolderID)
-				if err != nil {
+				for filename, err := range ocrGroup(ctx, engine, runManifest, imgPrep, group, &stats) {
 					log.Printf("ERROR procesando %s: %v", filename, err)
+					failedMu.Lock()
+					failed[filename] = err
+					failedMu.Unlock()
 				}
-			}(imgFilename)
+			}(group)
 		}
 		wg.Wait()
+		logDedupSavings(copyDuplicates(runManifest, clusters, failed), engine.Name())
 		log.Printf("✓ OCR completado. Tiempo total: %s", time.Since(startTime))
+		stats.log()
+		logFailureSummary(failed)
 	}
 
+	if err := runManifest.Flush(); err != nil {
+		log.Printf("[!] ADVERTENCIA: No se pudo guardar el manifiesto: %v", err)
+	}
 	log.Println("===== PASO 1 COMPLETADO =====")
 	log.Println("") // Línea en blanco para separar
 
-	// --- PASO 2: CONSTRUCCIÓN DEL SRT ---
-	log.Println("===== INICIANDO PASO 2: CREACIÓN DE ARCHIVO SRT =====")
+	// --- PASO 2: CONSTRUCCIÓN DE LOS SUBTÍTULOS ---
+	log.Println("===== INICIANDO PASO 2: CREACIÓN DE ARCHIVO DE SUBTÍTULOS =====")
 
-	outputSrtFileName := outputSrtFile
+	outputSrtFileName := outputBaseName + srtbuilder.Extension(outputOpts.Format)
 	if *useLocation {
 		wd, err := os.Getwd()
 		if err != nil {
 			log.Fatalf("No se pudo obtener el directorio de trabajo actual: %v", err)
 		}
-		outputSrtFileName = filepath.Base(wd) + ".srt"
+		outputSrtFileName = filepath.Base(wd) + srtbuilder.Extension(outputOpts.Format)
 		log.Printf("El archivo de salida se nombrará según la carpeta actual: %s", outputSrtFileName)
 	}
 
-	err = srtbuilder.CreateSrtFromTextFiles(textsFolder, outputSrtFileName, geminiClient)
+	err = srtbuilder.CreateSrtFromTextFiles(textsFolder, outputSrtFileName, geminiClient, runManifest, outputOpts)
 	if err != nil {
-		log.Fatalf("Fallo al crear el archivo SRT: %v", err)
+		log.Fatalf("Fallo al crear el archivo de subtítulos: %v", err)
 	}
 
 	log.Println("===== PROCESO FINALIZADO CON ÉXITO =====")
 }
+
+// logFailureSummary lista las imágenes que no se pudieron procesar. Quedan
+// marcadas como fallidas en el manifiesto y se reintentarán en la próxima ejecución.
+func logFailureSummary(failed map[string]error) {
+	if len(failed) == 0 {
+		return
+	}
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	log.Printf("[!] ADVERTENCIA: %d imágenes fallaron definitivamente y faltarán en los subtítulos:", len(failed))
+	for _, name := range names {
+		log.Printf("    - %s: %v", name, failed[name])
+	}
+	log.Println("    Vuelve a ejecutar el programa para reintentarlas.")
+}
+
+// logSkippedFiles lista los archivos de RGBImages que no se procesarán por no
+// tener una extensión de imagen conocida.
+func logSkippedFiles(skipped []string) {
+	if len(skipped) == 0 {
+		return
+	}
+	sort.Strings(skipped)
+	log.Printf("[!] ADVERTENCIA: Se ignoraron %d archivos de '%s' con una extensión desconocida (soportadas: %s):", len(skipped), imagesFolder, strings.Join(ocr.ImageExtensions, ", "))
+	for _, name := range skipped {
+		log.Printf("    - %s", name)
+	}
+}
+
+// textFilename devuelve el nombre del archivo de texto de una imagen: el mismo
+// nombre base con extensión .txt, que es de donde el paso 2 saca los tiempos.
+func textFilename(imageFilename string) string {
+	return strings.TrimSuffix(imageFilename, filepath.Ext(imageFilename)) + ".txt"
+}
+
+// dropTextCollisions quita de images las imágenes cuyo texto coincidiría con
+// el de otra (p. ej. 'foo.bmp' y 'foo.png', que irían ambas a 'foo.txt') y
+// las devuelve aparte agrupadas por nombre de texto. No se sabe cuál es la
+// buena, así que no se procesa ninguna de ellas.
+func dropTextCollisions(images []string) ([]string, map[string][]string) {
+	byText := make(map[string][]string, len(images))
+	for _, name := range images {
+		txt := textFilename(name)
+		byText[txt] = append(byText[txt], name)
+	}
+
+	var kept []string
+	collisions := make(map[string][]string)
+	for _, name := range images {
+		txt := textFilename(name)
+		if len(byText[txt]) > 1 {
+			collisions[txt] = byText[txt]
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept, collisions
+}
+
+// logTextCollisions avisa de las imágenes que dropTextCollisions dejó fuera.
+func logTextCollisions(collisions map[string][]string) {
+	if len(collisions) == 0 {
+		return
+	}
+	texts := make([]string, 0, len(collisions))
+	for txt := range collisions {
+		texts = append(texts, txt)
+	}
+	sort.Strings(texts)
+	log.Printf("[!] ADVERTENCIA: Se ignoraron %d grupos de imágenes de '%s' que comparten nombre y se guardarían en el mismo archivo de texto. Renómbralas para procesarlas:", len(texts), imagesFolder)
+	for _, txt := range texts {
+		log.Printf("    - %s -> %s", strings.Join(collisions[txt], ", "), txt)
+	}
+}
+
+// imageJob es una imagen de RGBImages que necesita OCR, con su entrada en el
+// manifiesto. La suma y el manifiesto se refieren siempre a la imagen
+// original, no a la preprocesada.
+type imageJob struct {
+	filename  string
+	imagePath string
+	textPath  string
+	entry     manifest.ImageEntry
+}
+
+// newImageJob devuelve nil si el manifiesto indica que la imagen ya tiene un
+// OCR válido, o si se adopta un texto de una ejecución anterior.
+func newImageJob(m *manifest.Manifest, engine ocr.Engine, filename string) (*imageJob, error) {
+	fullImagePath := filepath.Join(imagesFolder, filename)
+	fullTextPath := filepath.Join(textsFolder, textFilename(filename))
+
+	sum, err := manifest.Checksum(fullImagePath)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo calcular la suma de la imagen: %v", err)
+	}
+
+	if m.ImageDone(filename, sum, fullTextPath) {
+		log.Printf("[SKIP] '%s' ya se procesó en una ejecución anterior. Saltando OCR.", filename)
+		return nil, nil
+	}
+
+	// Textos de ejecuciones anteriores a la existencia del manifiesto: se
+	// adoptan si no están vacíos para no repetir un OCR ya hecho.
+	if _, known := m.Image(filename); !known {
+		if info, err := os.Stat(fullTextPath); err == nil && info.Size() > 0 {
+			log.Printf("[SKIP] El archivo de texto para '%s' ya existe. Registrándolo en el manifiesto.", filename)
+			return nil, m.RecordImage(filename, manifest.ImageEntry{
+				Status:   manifest.StatusDone,
+				SHA256:   sum,
+				TextFile: fullTextPath,
+			})
+		}
+	}
+
+	return &imageJob{
+		filename:  filename,
+		imagePath: fullImagePath,
+		textPath:  fullTextPath,
+		entry: manifest.ImageEntry{
+			SHA256:   sum,
+			TextFile: fullTextPath,
+			Engine:   engine.Name(),
+		},
+	}, nil
+}
+
+// record guarda el estado de la imagen en el manifiesto. Un fallo al guardar
+// no detiene el OCR: como mucho se repetirá en la próxima ejecución.
+func (j *imageJob) record(m *manifest.Manifest, status string, metadata map[string]string, ocrErr error) {
+	j.entry.Status = status
+	j.entry.Metadata = metadata
+	j.entry.Error = ""
+	if ocrErr != nil {
+		j.entry.Error = ocrErr.Error()
+	}
+	if err := m.RecordImage(j.filename, j.entry); err != nil {
+		log.Printf("[!] ADVERTENCIA: No se pudo actualizar el manifiesto para '%s': %v", j.filename, err)
+	}
+}
+
+// finish registra en el manifiesto el resultado del OCR de la imagen.
+func (j *imageJob) finish(m *manifest.Manifest, result *ocr.Result, ocrErr error) {
+	if ocrErr != nil {
+		j.record(m, manifest.StatusFailed, nil, ocrErr)
+		return
+	}
+	j.record(m, manifest.StatusDone, result.Metadata, nil)
+}
+
+// ocrImage reconoce una imagen con una petición propia al motor y registra el
+// resultado en el manifiesto.
+func ocrImage(ctx context.Context, engine ocr.Engine, m *manifest.Manifest, prep *imagePrep, job *imageJob) error {
+	job.record(m, manifest.StatusPending, nil, nil)
+	ctx = withRemoteRecorder(ctx, m, job)
+
+	var result *ocr.Result
+	ocrImagePath, ocrErr := prep.prepare(job.imagePath)
+	if ocrErr == nil {
+		result, ocrErr = ocr.ProcessFile(ctx, engine, ocrImagePath, job.textPath)
+	}
+	job.finish(m, result, ocrErr)
+	return ocrErr
+}
+
+// withRemoteRecorder devuelve un contexto que anota en el manifiesto de las
+// imágenes los recursos remotos temporales que cree el motor al reconocerlas.
+func withRemoteRecorder(ctx context.Context, m *manifest.Manifest, jobs ...*imageJob) context.Context {
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		names[i] = job.filename
+	}
+	return ocr.WithRemoteHook(ctx, func(key, id string) {
+		if err := m.RecordImageMetadata(names, key, id); err != nil {
+			log.Printf("[!] ADVERTENCIA: No se pudo anotar %s=%s en el manifiesto: %v", key, id, err)
+		}
+	})
+}
+
+// deleteInterruptedDocs borra los documentos temporales que el manifiesto
+// anotó para imágenes que quedaron a medias en la ejecución anterior, sin
+// esperar a que -sweep-older-than los considere huérfanos.
+func deleteInterruptedDocs(ctx context.Context, engine *gdrive.Engine, m *manifest.Manifest) {
+	docs := m.PendingMetadata(gdrive.MetadataDocID)
+	if len(docs) == 0 {
+		return
+	}
+	deleted := 0
+	for name, id := range docs {
+		if err := engine.DeleteDoc(ctx, id); err != nil {
+			log.Printf("[!] ADVERTENCIA: No se pudo borrar el doc temporal %s de '%s': %v", id, name, err)
+			continue
+		}
+		deleted++
+	}
+	log.Printf("✓ Se limpiaron %d documentos temporales de imágenes interrumpidas.", deleted)
+}
+
+// engineConfig agrupa los flags que afectan a la creación del motor de OCR.
+type engineConfig struct {
+	name           string
+	tesseractBin   string
+	tesseractLang  string
+	visionModel    string
+	geminiClient   *genai.Client
+	sweepOlderThan time.Duration
+	driveRPS       float64
+	driveBurst     int
+	driveHTML      bool
+}
+
+// newOCREngine construye el motor de OCR seleccionado con el flag -engine.
+func newOCREngine(cfg engineConfig) (ocr.Engine, error) {
+	switch cfg.name {
+	case gdrive.EngineName:
+		srv, err := gdrive.AuthenticateAndGetService()
+		if err != nil {
+			return nil, fmt.Errorf("fallo en la autenticación: %v", err)
+		}
+		log.Println("✓ Autenticación exitosa.")
+
+		driveFolderID, err := gdrive.GetOrCreateFolder(srv, driveTempFolder)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo obtener/crear la carpeta de Drive: %v", err)
+		}
+
+		engine := gdrive.NewEngine(srv, driveFolderID)
+		engine.ExportHTML = cfg.driveHTML
+		if cfg.driveRPS > 0 {
+			burst := cfg.driveBurst
+			if burst == 0 {
+				burst = max(1, int(math.Ceil(cfg.driveRPS)))
+			}
+			engine.Limiter = rate.NewLimiter(rate.Limit(cfg.driveRPS), burst)
+			log.Printf("✓ Límite de Drive: %.2f peticiones/s (ráfaga de %d).", cfg.driveRPS, burst)
+		}
+
+		// Limpiar los documentos que dejaron ejecuciones interrumpidas, ya con
+		// el límite de peticiones y los reintentos del motor.
+		if cfg.sweepOlderThan > 0 {
+			orphans, err := engine.Sweep(context.Background(), gdrive.SweepOptions{OlderThan: cfg.sweepOlderThan})
+			if err != nil {
+				log.Printf("[!] ADVERTENCIA: No se pudo limpiar la carpeta temporal: %v", err)
+			} else if len(orphans) > 0 {
+				log.Printf("✓ Se borraron %d documentos huérfanos de ejecuciones anteriores.", len(orphans))
+			}
+		}
+		return engine, nil
+	case tesseract.EngineName:
+		return tesseract.NewEngine(cfg.tesseractBin, cfg.tesseractLang)
+	case geminifix.VisionEngineName:
+		return geminifix.NewVisionEngine(cfg.geminiClient, cfg.visionModel), nil
+	default:
+		return nil, fmt.Errorf("motor de OCR desconocido: %q", cfg.name)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yoshi70001/googleDocsOCR/gdrive"
+	"github.com/yoshi70001/googleDocsOCR/gdrive/drivefake"
+)
+
+// TestPipelineWithFakeDrive ejecuta main() completo (OCR + SRT) contra el
+// servidor falso de Drive.
+func TestPipelineWithFakeDrive(t *testing.T) {
+	fake := drivefake.NewServer(func(name string, image []byte) string {
+		return "\ufeff\n\n" + string(image)
+	})
+	defer fake.Close()
+	t.Setenv(gdrive.EndpointEnv, fake.Endpoint())
+	t.Setenv("GEMINI_API_KEY", "")
+
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir(imagesFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	frames := map[string]string{
+		"0_00_01_000__0_00_02_500_0001.png": "Hola",
+		"0_00_03_000__0_00_04_000_0002.png": "Adiós",
+	}
+	for name, text := range frames {
+		if err := os.WriteFile(filepath.Join(imagesFolder, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.Args = []string{"googleDocsOCR"}
+	main()
+
+	got, err := os.ReadFile(outputBaseName + ".srt")
+	if err != nil {
+		t.Fatalf("no se generó el SRT: %v", err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,500\nHola\n\n" +
+		"2\n00:00:03,000 --> 00:00:04,000\nAdiós\n\n"
+	if string(got) != want {
+		t.Errorf("SRT generado:\n%s\nse esperaba:\n%s", got, want)
+	}
+
+	if n := len(fake.Files()); n != 1 {
+		t.Errorf("quedaron %d archivos en Drive, se esperaba solo la carpeta temporal", n)
+	}
+}
+
+func TestDropTextCollisions(t *testing.T) {
+	images := []string{"001.png", "foo.bmp", "foo.png", "bar.jpg", "bar.JPG", "baz.webp"}
+	kept, collisions := dropTextCollisions(images)
+
+	if want := []string{"001.png", "baz.webp"}; !slices.Equal(kept, want) {
+		t.Errorf("imágenes conservadas = %q, se esperaba %q", kept, want)
+	}
+	want := map[string][]string{
+		"foo.txt": {"foo.bmp", "foo.png"},
+		"bar.txt": {"bar.jpg", "bar.JPG"},
+	}
+	if len(collisions) != len(want) {
+		t.Fatalf("colisiones = %v, se esperaba %v", collisions, want)
+	}
+	for txt, names := range want {
+		if !slices.Equal(collisions[txt], names) {
+			t.Errorf("colisión de %s = %q, se esperaba %q", txt, collisions[txt], names)
+		}
+	}
+}
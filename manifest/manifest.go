@@ -0,0 +1,412 @@
+// Package manifest guarda el progreso de una ejecución en un archivo JSON para
+// que, si se interrumpe, la siguiente reanude el OCR y la corrección sin repetir
+// trabajo ya hecho (y ya pagado).
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultFile es el nombre del manifiesto en el directorio de trabajo.
+const DefaultFile = "ocr_manifest.json"
+
+// currentVersion es la versión del formato del archivo.
+const currentVersion = 1
+
+// Estados posibles de una imagen o de un lote. Una imagen queda "pending"
+// mientras se procesa, de modo que un texto a medio escribir por una ejecución
+// interrumpida nunca se toma por bueno.
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// ImageEntry es el estado del OCR de una imagen de RGBImages.
+type ImageEntry struct {
+	Status string `json:"status"`
+	// SHA256 es la suma de la imagen de origen; si cambia, se repite el OCR.
+	SHA256   string `json:"sha256"`
+	TextFile string `json:"text_file"`
+	Engine   string `json:"engine,omitempty"`
+	// Metadata son los datos devueltos por el motor (p. ej. drive_doc_id, que
+	// se anota con RecordImageMetadata en cuanto se crea el documento).
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// BatchEntry es el resultado de la corrección de un lote con Gemini.
+type BatchEntry struct {
+	Status    string    `json:"status"`
+	Corrected []string  `json:"corrected,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// saveInterval es el tiempo mínimo entre dos escrituras del manifiesto por
+// cambios de imágenes. Reescribirlo tras cada imagen bloquearía a los workers
+// en episodios de miles de fotogramas; lo que quede sin escribir al
+// interrumpirse el programa solo obliga a repetir unos segundos de OCR.
+const saveInterval = 2 * time.Second
+
+// JournalSuffix es la extensión del diario que acompaña al manifiesto. En él
+// RecordImageMetadata añade una línea por dato en lugar de reescribir el
+// manifiesto entero; Load lo incorpora y se borra en cuanto el manifiesto
+// escrito contiene todo lo anotado.
+const JournalSuffix = ".journal"
+
+// journalLine es una línea del diario: el dato key=value de varias imágenes.
+type journalLine struct {
+	Images []string `json:"images"`
+	Key    string   `json:"key"`
+	Value  string   `json:"value"`
+}
+
+// Manifest es el estado persistente de una ejecución. Es seguro para uso concurrente.
+type Manifest struct {
+	path string
+	mu   sync.Mutex
+	// changes cuenta las modificaciones en memoria y snapshot la última que
+	// se serializó para escribirla; lastSave es el momento de esa serialización.
+	changes  uint64
+	snapshot uint64
+	lastSave time.Time
+	// saveMu ordena las escrituras del archivo; written es la modificación
+	// que contiene, para que una instantánea antigua no pise a una más nueva,
+	// y writes cuenta las escrituras.
+	saveMu  sync.Mutex
+	written uint64
+	writes  int
+	// journaled es la modificación de la última línea del diario; journalMu
+	// ordena las líneas y el borrado del diario.
+	journaled uint64
+	journalMu sync.Mutex
+
+	Version int                    `json:"version"`
+	Images  map[string]*ImageEntry `json:"images"`
+	Batches map[string]*BatchEntry `json:"batches"`
+}
+
+// Load lee el manifiesto de path. Si el archivo no existe devuelve uno vacío
+// que se creará al guardar por primera vez.
+func Load(path string) (*Manifest, error) {
+	m := &Manifest{
+		path:    path,
+		Version: currentVersion,
+		Images:  make(map[string]*ImageEntry),
+		Batches: make(map[string]*BatchEntry),
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Puede haber diario aunque el manifiesto aún no se hubiera escrito.
+		if err := m.replayJournal(); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer el manifiesto '%s': %w", path, err)
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("el manifiesto '%s' está dañado: %w", path, err)
+	}
+	if m.Version != currentVersion {
+		return nil, fmt.Errorf("versión de manifiesto no soportada en '%s': %d", path, m.Version)
+	}
+	if m.Images == nil {
+		m.Images = make(map[string]*ImageEntry)
+	}
+	if m.Batches == nil {
+		m.Batches = make(map[string]*BatchEntry)
+	}
+	if err := m.replayJournal(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// replayJournal aplica las líneas del diario de una ejecución anterior. Una
+// línea a medio escribir por una interrupción se descarta.
+func (m *Manifest) replayJournal() error {
+	b, err := os.ReadFile(m.journalPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("no se pudo leer el diario del manifiesto: %w", err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, len(b)+1)
+	for sc.Scan() {
+		var line journalLine
+		if json.Unmarshal(sc.Bytes(), &line) != nil {
+			continue
+		}
+		m.setMetadata(line.Images, line.Key, line.Value)
+	}
+	// Se escribirá con el próximo guardado, que borrará el diario.
+	m.changes++
+	m.journaled = m.changes
+	return nil
+}
+
+// journalPath devuelve la ruta del diario del manifiesto.
+func (m *Manifest) journalPath() string {
+	return m.path + JournalSuffix
+}
+
+// Path devuelve la ruta del archivo del manifiesto.
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+// ImageDone indica si la imagen ya tiene un OCR válido: marcado como hecho,
+// con la misma suma de comprobación y con su archivo de texto. El texto puede
+// estar vacío (imágenes sin subtítulo): un texto a medio escribir nunca llega
+// a marcarse como hecho.
+func (m *Manifest) ImageDone(name, sha256sum, textPath string) bool {
+	m.mu.Lock()
+	entry, ok := m.Images[name]
+	m.mu.Unlock()
+	if !ok || entry.Status != StatusDone || entry.SHA256 != sha256sum {
+		return false
+	}
+	_, err := os.Stat(textPath)
+	return err == nil
+}
+
+// Image devuelve una copia de la entrada de una imagen.
+func (m *Manifest) Image(name string) (ImageEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.Images[name]
+	if !ok {
+		return ImageEntry{}, false
+	}
+	return *entry, true
+}
+
+// RecordImage guarda el estado de una imagen. El estado pendiente solo se
+// guarda en memoria: si el programa se interrumpe, una imagen sin entrada se
+// procesa igual que una pendiente. El resto de estados se escriben en disco
+// como mucho cada saveInterval; Flush escribe lo que falte.
+func (m *Manifest) RecordImage(name string, entry ImageEntry) error {
+	m.mu.Lock()
+	entry.UpdatedAt = time.Now()
+	m.Images[name] = &entry
+	m.changes++
+	m.mu.Unlock()
+	if entry.Status == StatusPending {
+		return nil
+	}
+	return m.save(false)
+}
+
+// RecordImageMetadata añade un dato a la entrada de cada imagen indicada y lo
+// anota enseguida en el diario. Es para datos que deben estar en disco en
+// cuanto existen, como el ID de un documento temporal que hay que borrar si
+// el programa se interrumpe. El manifiesto se escribe como con RecordImage.
+func (m *Manifest) RecordImageMetadata(names []string, key, value string) error {
+	line, err := json.Marshal(journalLine{Images: names, Key: key, Value: value})
+	if err != nil {
+		return fmt.Errorf("no se pudo serializar el diario del manifiesto: %w", err)
+	}
+
+	m.journalMu.Lock()
+	m.mu.Lock()
+	m.setMetadata(names, key, value)
+	m.changes++
+	m.journaled = m.changes
+	m.mu.Unlock()
+	err = m.appendJournal(append(line, '\n'))
+	m.journalMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return m.save(false)
+}
+
+// appendJournal añade una línea al diario. Se llama con journalMu tomado.
+func (m *Manifest) appendJournal(line []byte) error {
+	f, err := os.OpenFile(m.journalPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("no se pudo abrir el diario del manifiesto: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("no se pudo escribir el diario del manifiesto: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("no se pudo escribir el diario del manifiesto: %w", err)
+	}
+	return nil
+}
+
+// setMetadata pone key=value en los metadatos de las imágenes, creando como
+// pendientes las que no tengan entrada. Se llama con mu tomado.
+func (m *Manifest) setMetadata(names []string, key, value string) {
+	for _, name := range names {
+		entry, ok := m.Images[name]
+		if !ok {
+			entry = &ImageEntry{Status: StatusPending}
+			m.Images[name] = entry
+		}
+		// Copia nueva: el mapa anterior puede ser el de un resultado del motor.
+		metadata := make(map[string]string, len(entry.Metadata)+1)
+		for k, v := range entry.Metadata {
+			metadata[k] = v
+		}
+		metadata[key] = value
+		entry.Metadata = metadata
+		entry.UpdatedAt = time.Now()
+	}
+}
+
+// PendingMetadata devuelve, para las imágenes que quedaron pendientes, el
+// valor del dato key de sus metadatos, por nombre de imagen.
+func (m *Manifest) PendingMetadata(key string) map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make(map[string]string)
+	for name, entry := range m.Images {
+		if value, ok := entry.Metadata[key]; ok && entry.Status == StatusPending {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// Flush escribe en disco los cambios pendientes de guardar, incluidos los
+// anotados en el diario, y borra el diario.
+func (m *Manifest) Flush() error {
+	return m.save(true)
+}
+
+// CorrectedBatch devuelve la corrección guardada para la clave de lote indicada.
+func (m *Manifest) CorrectedBatch(key string) ([]string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.Batches[key]
+	if !ok || entry.Status != StatusDone {
+		return nil, false
+	}
+	return append([]string(nil), entry.Corrected...), true
+}
+
+// RecordBatch guarda la corrección de un lote y persiste el manifiesto.
+func (m *Manifest) RecordBatch(key string, corrected []string) error {
+	m.mu.Lock()
+	m.Batches[key] = &BatchEntry{
+		Status:    StatusDone,
+		Corrected: append([]string(nil), corrected...),
+		UpdatedAt: time.Now(),
+	}
+	m.changes++
+	m.mu.Unlock()
+	// Cada lote ha costado una llamada a Gemini: se escribe enseguida.
+	return m.save(true)
+}
+
+// save escribe el manifiesto si tiene cambios sin guardar. Sin force no
+// escribe si la última escritura fue hace menos de saveInterval. La
+// serialización se hace con m.mu tomado, pero la escritura no, para no
+// bloquear a quien registre imágenes mientras tanto.
+func (m *Manifest) save(force bool) error {
+	m.mu.Lock()
+	if m.changes == m.snapshot || (!force && time.Since(m.lastSave) < saveInterval) {
+		m.mu.Unlock()
+		return nil
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("no se pudo serializar el manifiesto: %w", err)
+	}
+	seq := m.changes
+	m.snapshot, m.lastSave = seq, time.Now()
+	m.mu.Unlock()
+
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+	if seq <= m.written {
+		return nil
+	}
+	if err := m.writeFile(b); err != nil {
+		// La próxima llamada a save debe volver a intentarlo.
+		m.mu.Lock()
+		if m.snapshot == seq {
+			m.snapshot = m.written
+		}
+		m.mu.Unlock()
+		return err
+	}
+	m.written = seq
+	m.writes++
+	return m.dropJournal(seq)
+}
+
+// dropJournal borra el diario si el manifiesto escrito en la modificación
+// seq ya contiene todas sus líneas.
+func (m *Manifest) dropJournal(seq uint64) error {
+	m.journalMu.Lock()
+	defer m.journalMu.Unlock()
+	m.mu.Lock()
+	covered := m.journaled <= seq
+	m.mu.Unlock()
+	if !covered {
+		return nil
+	}
+	if err := os.Remove(m.journalPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no se pudo borrar el diario del manifiesto: %w", err)
+	}
+	return nil
+}
+
+// writeFile escribe b en un archivo temporal y lo renombra, para que una
+// interrupción nunca deje un JSON a medias.
+func (m *Manifest) writeFile(b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("no se pudo crear el manifiesto temporal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("no se pudo escribir el manifiesto: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("no se pudo escribir el manifiesto: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("no se pudo reemplazar el manifiesto: %w", err)
+	}
+	return nil
+}
+
+// Checksum calcula la suma SHA-256 de un archivo en hexadecimal.
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,277 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImageDoneWithEmptyText(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFile)
+	textPath := filepath.Join(dir, "vacia.txt")
+	if err := os.WriteFile(textPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RecordImage("vacia.png", ImageEntry{Status: StatusDone, SHA256: "abc", TextFile: textPath}); err != nil {
+		t.Fatalf("RecordImage: %v", err)
+	}
+	if err := m.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// La ejecución siguiente carga el manifiesto del disco.
+	next, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.ImageDone("vacia.png", "abc", textPath) {
+		t.Error("una imagen hecha con texto vacío debe saltarse al reanudar")
+	}
+}
+
+func TestLoadMissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := Load(filepath.Join(dir, "no-existe.json"))
+	if err != nil {
+		t.Fatalf("Load de un archivo inexistente: %v", err)
+	}
+	if len(m.Images) != 0 || len(m.Batches) != 0 {
+		t.Errorf("se esperaba un manifiesto vacío, hay %d imágenes y %d lotes", len(m.Images), len(m.Batches))
+	}
+
+	for name, content := range map[string]string{
+		"corrupto.json": `{"version": 1, "images": {`,
+		"version.json":  `{"version": 99}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%s): se esperaba un error", name)
+		}
+	}
+}
+
+func TestImageDoneRedo(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "img.png")
+	textPath := filepath.Join(dir, "img.txt")
+	if err := os.WriteFile(imagePath, []byte("pixeles"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(textPath, []byte("Hola"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := Checksum(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(filepath.Join(dir, DefaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.RecordImage("pendiente.png", ImageEntry{Status: StatusPending, SHA256: sum, TextFile: textPath})
+	m.RecordImage("img.png", ImageEntry{Status: StatusDone, SHA256: sum, TextFile: textPath})
+
+	if !m.ImageDone("img.png", sum, textPath) {
+		t.Error("img.png debería estar hecha")
+	}
+	if m.ImageDone("pendiente.png", sum, textPath) {
+		t.Error("una imagen pendiente debe repetirse")
+	}
+
+	// La imagen cambia: su suma ya no coincide.
+	if err := os.WriteFile(imagePath, []byte("otros pixeles"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newSum, err := Checksum(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newSum == sum {
+		t.Fatal("la suma no cambió al cambiar la imagen")
+	}
+	if m.ImageDone("img.png", newSum, textPath) {
+		t.Error("una imagen con otra suma debe repetirse")
+	}
+
+	// El texto desapareció.
+	if err := os.Remove(textPath); err != nil {
+		t.Fatal(err)
+	}
+	if m.ImageDone("img.png", sum, textPath) {
+		t.Error("una imagen sin archivo de texto debe repetirse")
+	}
+}
+
+func TestPendingNotPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RecordImage("img.png", ImageEntry{Status: StatusPending}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("el estado pendiente no debería escribir el manifiesto (Stat: %v)", err)
+	}
+
+	// El ID de un documento temporal sí se anota enseguida, en el diario.
+	if err := m.RecordImageMetadata([]string{"img.png"}, "drive_doc_id", "doc-1"); err != nil {
+		t.Fatal(err)
+	}
+	next, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next.PendingMetadata("drive_doc_id"); got["img.png"] != "doc-1" || len(got) != 1 {
+		t.Errorf("PendingMetadata = %v, se esperaba img.png=doc-1", got)
+	}
+}
+
+func TestMetadataWritesPerImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const images = 200
+	for i := range images {
+		name := fmt.Sprintf("%04d.png", i)
+		if err := m.RecordImage(name, ImageEntry{Status: StatusPending}); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.RecordImageMetadata([]string{name}, "drive_doc_id", "doc-"+name); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.RecordImage(name, ImageEntry{Status: StatusDone}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Solo el ID de la primera imagen reescribe el manifiesto; lo demás
+	// espera a saveInterval y los IDs siguientes van al diario.
+	if m.writes != 1 {
+		t.Errorf("escrituras del manifiesto = %d para %d imágenes, se esperaba 1", m.writes, images)
+	}
+	journal, err := os.ReadFile(path + JournalSuffix)
+	if err != nil {
+		t.Fatalf("no se escribió el diario: %v", err)
+	}
+	if lines := strings.Count(string(journal), "\n"); lines != images-1 {
+		t.Errorf("líneas del diario = %d, se esperaban %d", lines, images-1)
+	}
+
+	// Una ejecución interrumpida ahora recupera todos los IDs: el primero del
+	// manifiesto y el resto del diario.
+	interrupted, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := interrupted.PendingMetadata("drive_doc_id"); len(got) != images || got["0000.png"] != "doc-0000.png" || got["0199.png"] != "doc-0199.png" {
+		t.Errorf("PendingMetadata tras la interrupción = %d IDs, se esperaban %d", len(got), images)
+	}
+
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if m.writes != 2 {
+		t.Errorf("escrituras tras Flush = %d, se esperaban 2", m.writes)
+	}
+	if _, err := os.Stat(path + JournalSuffix); !os.IsNotExist(err) {
+		t.Errorf("Flush debería borrar el diario (Stat: %v)", err)
+	}
+	next, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next.PendingMetadata("drive_doc_id"); len(got) != 0 {
+		t.Errorf("PendingMetadata tras Flush = %v, no se esperaba ninguno", got)
+	}
+}
+
+func TestJournalWithoutManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	// Una línea completa y otra a medio escribir por la interrupción.
+	journal := `{"images":["a.png","b.png"],"key":"drive_doc_id","value":"doc-1"}` + "\n" + `{"images":["c.p`
+	if err := os.WriteFile(path+JournalSuffix, []byte(journal), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := m.PendingMetadata("drive_doc_id")
+	if len(got) != 2 || got["a.png"] != "doc-1" || got["b.png"] != "doc-1" {
+		t.Errorf("PendingMetadata = %v, se esperaba a.png y b.png con doc-1", got)
+	}
+}
+
+func TestBatchRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.CorrectedBatch("lote"); ok {
+		t.Error("CorrectedBatch de un lote desconocido debería fallar")
+	}
+	corrected := []string{"Hola", "Adiós"}
+	if err := m.RecordBatch("lote", corrected); err != nil {
+		t.Fatalf("RecordBatch: %v", err)
+	}
+	corrected[0] = "modificado"
+
+	next, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := next.CorrectedBatch("lote")
+	if !ok || len(got) != 2 || got[0] != "Hola" || got[1] != "Adiós" {
+		t.Errorf("CorrectedBatch = %q, %v; se esperaba [Hola Adiós]", got, ok)
+	}
+}
+
+func TestSaveIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFile)
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := m.RecordBatch("lote", []string{"texto"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.RecordImage("img.png", ImageEntry{Status: StatusDone})
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, DefaultFile+".tmp-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 {
+		t.Errorf("quedaron archivos temporales: %v", leftovers)
+	}
+	next, err := Load(path)
+	if err != nil {
+		t.Fatalf("el manifiesto guardado no se puede leer: %v", err)
+	}
+	if _, ok := next.Image("img.png"); !ok {
+		t.Error("Flush no escribió la última imagen")
+	}
+}
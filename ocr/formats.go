@@ -0,0 +1,57 @@
+// ocr/formats.go
+package ocr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	_ "github.com/yoshi70001/googleDocsOCR/imagecodecs"
+)
+
+// ImageExtensions son las extensiones de las imágenes que se pueden procesar.
+var ImageExtensions = []string{".bmp", ".gif", ".jpeg", ".jpg", ".png", ".tif", ".tiff", ".webp"}
+
+// IsImageFile indica si el nombre tiene una de ImageExtensions.
+func IsImageFile(name string) bool {
+	return slices.Contains(ImageExtensions, strings.ToLower(filepath.Ext(name)))
+}
+
+// FormatAccepter lo implementan los motores que solo aceptan algunos formatos
+// de imagen. Los que no lo implementan reciben la imagen tal cual.
+type FormatAccepter interface {
+	// AcceptsFormat indica si el motor acepta imágenes en el formato dado,
+	// con el nombre que devuelve image.DecodeConfig ("png", "jpeg", "gif",
+	// "bmp", "tiff" o "webp").
+	AcceptsFormat(format string) bool
+}
+
+// toAcceptedFormat devuelve la imagen convertida a PNG si el motor no acepta
+// su formato, o tal cual en otro caso. Las imágenes cuyo formato no se
+// reconoce también se pasan tal cual: el motor dará el error si corresponde.
+func toAcceptedFormat(engine Engine, data []byte, filename string) ([]byte, error) {
+	accepter, ok := engine.(FormatAccepter)
+	if !ok {
+		return data, nil
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || accepter.AcceptsFormat(format) {
+		return data, nil
+	}
+
+	log.Printf("    - Convirtiendo la imagen de %s a PNG (el motor %s no acepta ese formato)...", format, engine.Name())
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo decodificar la imagen %s (%s): %w", filename, format, err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("no se pudo convertir la imagen %s a PNG: %w", filename, err)
+	}
+	return buf.Bytes(), nil
+}
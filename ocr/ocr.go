@@ -0,0 +1,75 @@
+// Package ocr define la interfaz común que deben cumplir los motores de OCR,
+// de forma que main.go pueda cambiar de backend sin conocer sus detalles.
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Result es el texto reconocido en una imagen junto con datos adicionales
+// propios de cada motor (IDs remotos, idioma usado, etc.).
+type Result struct {
+	Text     string
+	Metadata map[string]string
+}
+
+// Engine es un motor capaz de extraer texto de una imagen.
+type Engine interface {
+	// Name devuelve el identificador del motor (el mismo que se usa en el flag -engine).
+	Name() string
+	// Recognize extrae el texto de los bytes de una imagen. filename es el nombre
+	// original del archivo y sirve para nombrar recursos temporales y para los logs.
+	Recognize(ctx context.Context, image []byte, filename string) (*Result, error)
+}
+
+// remoteHookKey es la clave de contexto del hook de WithRemoteHook.
+type remoteHookKey struct{}
+
+// WithRemoteHook devuelve un contexto con el que los motores avisan, mediante
+// NotifyRemote, de cada recurso remoto temporal que crean (p. ej. un documento
+// de Drive) en cuanto existe. Sirve para anotarlo antes de que termine el OCR,
+// por si el programa se interrumpe antes de borrarlo.
+func WithRemoteHook(ctx context.Context, fn func(key, id string)) context.Context {
+	return context.WithValue(ctx, remoteHookKey{}, fn)
+}
+
+// NotifyRemote llama al hook de WithRemoteHook del contexto, si lo hay.
+func NotifyRemote(ctx context.Context, key, id string) {
+	if fn, ok := ctx.Value(remoteHookKey{}).(func(key, id string)); ok {
+		fn(key, id)
+	}
+}
+
+// ProcessFile lee una imagen del disco, la pasa por el motor y guarda el texto
+// reconocido en textOutputPath.
+func ProcessFile(ctx context.Context, engine Engine, imagePath, textOutputPath string) (*Result, error) {
+	imageFileName := filepath.Base(imagePath)
+	log.Printf("[+] Iniciando procesamiento para: %s (motor: %s)", imageFileName, engine.Name())
+
+	image, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir la imagen local %s: %w", imagePath, err)
+	}
+
+	image, err = toAcceptedFormat(engine, image, imageFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := engine.Recognize(ctx, image, imageFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("    - Guardando texto en %s", textOutputPath)
+	if err := os.WriteFile(textOutputPath, []byte(result.Text), 0644); err != nil {
+		return nil, fmt.Errorf("no se pudo guardar el archivo de texto: %w", err)
+	}
+
+	log.Printf("[✓] Procesamiento completado para: %s", imageFileName)
+	return result, nil
+}
@@ -0,0 +1,89 @@
+// output.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/yoshi70001/googleDocsOCR/geminifix"
+	"github.com/yoshi70001/googleDocsOCR/srtbuilder"
+)
+
+// outputFlags agrupa los flags que controlan el procesado y la escritura del
+// subtítulo, comunes al flujo principal y al subcomando "fix".
+type outputFlags struct {
+	opts       srtbuilder.Options
+	assMarginH int
+}
+
+// register declara los flags en fs con sus valores por defecto.
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	opts := &o.opts
+	fs.StringVar(&opts.Format, "format", srtbuilder.FormatSRT, "Formato de salida: "+strings.Join(srtbuilder.Formats, ", "))
+	fs.StringVar(&opts.VTTCueSettings, "vtt-cue-settings", "", "Ajustes de cue WebVTT añadidos a cada subtítulo, p. ej. \"line:85% align:center\" (formato vtt)")
+	addRetimeFlags(fs, &opts.Retime)
+	opts.Merge = srtbuilder.DefaultMergeOptions
+	fs.BoolVar(&opts.Merge.Enabled, "merge", opts.Merge.Enabled, "Fusionar subtítulos consecutivos con el mismo texto (desactivado por defecto)")
+	fs.Float64Var(&opts.Merge.Threshold, "merge-threshold", opts.Merge.Threshold, "Distancia de edición normalizada (0-1) hasta la que dos textos se consideran iguales al fusionar (0 = solo idénticos)")
+	fs.DurationVar(&opts.Merge.MaxGap, "merge-max-gap", opts.Merge.MaxGap, "Hueco máximo entre dos subtítulos para fusionarlos")
+	opts.Timing = srtbuilder.DefaultTimingOptions
+	fs.BoolVar(&opts.Timing.Enabled, "timing", opts.Timing.Enabled, "Ajustar los tiempos: duración mínima y máxima, solapamientos, huecos y velocidad de lectura (desactivado por defecto)")
+	fs.DurationVar(&opts.Timing.MinDuration, "min-duration", opts.Timing.MinDuration, "Duración mínima de un subtítulo (0 para desactivar)")
+	fs.DurationVar(&opts.Timing.MaxDuration, "max-duration", opts.Timing.MaxDuration, "Duración máxima de un subtítulo (0 para desactivar)")
+	fs.DurationVar(&opts.Timing.CloseGap, "close-gap", opts.Timing.CloseGap, "Cerrar los huecos entre subtítulos menores que esto (0 para desactivar)")
+	fs.Float64Var(&opts.Timing.CharsPerSecond, "cps", opts.Timing.CharsPerSecond, "Velocidad de lectura en caracteres por segundo para alargar subtítulos cortos (0 para desactivar)")
+	opts.ASSStyle = srtbuilder.DefaultASSStyle
+	fs.StringVar(&opts.ASSStyle.FontName, "ass-font", opts.ASSStyle.FontName, "Fuente del estilo por defecto (formato ass)")
+	fs.IntVar(&opts.ASSStyle.FontSize, "ass-font-size", opts.ASSStyle.FontSize, "Tamaño de fuente del estilo por defecto, a 1920x1080 (formato ass)")
+	fs.Float64Var(&opts.ASSStyle.Outline, "ass-outline", opts.ASSStyle.Outline, "Grosor del borde del texto (formato ass)")
+	fs.IntVar(&opts.ASSStyle.MarginV, "ass-margin-v", opts.ASSStyle.MarginV, "Margen vertical en píxeles (formato ass)")
+	fs.IntVar(&o.assMarginH, "ass-margin-h", opts.ASSStyle.MarginL, "Margen izquierdo y derecho en píxeles (formato ass)")
+	fs.StringVar(&opts.ASSTemplate, "ass-template", "", "Archivo .ass cuya cabecera y primer estilo se usan en lugar de los flags -ass-* (formato ass)")
+}
+
+// options valida los flags ya parseados y devuelve las opciones resultantes.
+func (o *outputFlags) options() (srtbuilder.Options, error) {
+	opts := o.opts
+	opts.ASSStyle.MarginL, opts.ASSStyle.MarginR = o.assMarginH, o.assMarginH
+
+	if !slices.Contains(srtbuilder.Formats, opts.Format) {
+		return opts, fmt.Errorf("formato de salida desconocido: %q (soportados: %s)", opts.Format, strings.Join(srtbuilder.Formats, ", "))
+	}
+	if opts.ASSTemplate != "" {
+		if _, err := os.Stat(opts.ASSTemplate); err != nil {
+			return opts, fmt.Errorf("no se puede leer la plantilla ASS: %v", err)
+		}
+	}
+	if err := opts.Retime.Validate(); err != nil {
+		return opts, fmt.Errorf("opciones de tiempo inválidas: %v", err)
+	}
+	if opts.Merge.Threshold < 0 || opts.Merge.Threshold > 1 {
+		return opts, fmt.Errorf("-merge-threshold debe estar entre 0 y 1 (valor: %g)", opts.Merge.Threshold)
+	}
+	return opts, nil
+}
+
+// newCorrectionClient crea el cliente de Gemini para la corrección de texto si
+// se pidió y hay clave de API. Devuelve nil si no se usará Gemini.
+func newCorrectionClient(ctx context.Context, useGemini bool) *genai.Client {
+	if !useGemini {
+		log.Println("[!] Gemini desactivado. No se realizará corrección de IA.")
+		return nil
+	}
+	if os.Getenv("GEMINI_API_KEY") == "" {
+		log.Println("[!] ADVERTENCIA: No se encontró la GEMINI_API_KEY. Se procederá sin corrección de IA.")
+		return nil
+	}
+	client, err := geminifix.NewClient(ctx)
+	if err != nil {
+		log.Fatalf("Fallo al inicializar el cliente de Gemini: %v", err)
+	}
+	log.Println("✓ Cliente de Gemini inicializado.")
+	return client
+}
@@ -0,0 +1,103 @@
+// Package phash calcula un hash perceptual (dHash) de las imágenes para
+// detectar fotogramas casi idénticos sin depender de los bytes exactos del
+// archivo, que cambian con el ruido de compresión.
+package phash
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+	"os"
+
+	_ "github.com/yoshi70001/googleDocsOCR/imagecodecs"
+)
+
+// Tamaño por defecto de la rejilla. Las tiras de subtítulo son muy anchas, así
+// que se usan más columnas que filas para distinguir frases de longitud parecida.
+const (
+	DefaultWidth  = 64
+	DefaultHeight = 8
+)
+
+// minDiff es la diferencia mínima de gris entre dos celdas vecinas para que el
+// bit se active. Sin ella, el ruido de las zonas lisas del fondo cambiaría
+// bits al azar entre fotogramas iguales.
+const minDiff = 4
+
+// Hash es un dHash: un bit por celda de la rejilla, activo si la celda es
+// claramente más clara que su vecina de la derecha.
+type Hash struct {
+	bits []uint64
+	n    int
+}
+
+// Len devuelve el número de bits del hash.
+func (h Hash) Len() int {
+	return h.n
+}
+
+// Distance devuelve la fracción de bits distintos entre dos hashes (0 si son
+// iguales, 1 si no coincide ninguno). Hashes de tamaños distintos están a 1.
+func Distance(a, b Hash) float64 {
+	if a.n != b.n || a.n == 0 {
+		return 1
+	}
+	diff := 0
+	for i := range a.bits {
+		diff += bits.OnesCount64(a.bits[i] ^ b.bits[i])
+	}
+	return float64(diff) / float64(a.n)
+}
+
+// DHash reduce la imagen a una rejilla de (width+1) x height celdas en escala
+// de grises, promediando los píxeles de cada una, y compara cada celda con su
+// vecina de la derecha.
+func DHash(img image.Image, width, height int) Hash {
+	b := img.Bounds()
+	cols := width + 1
+	sums := make([]float64, cols*height)
+	counts := make([]int, cols*height)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := (y - b.Min.Y) * height / b.Dy()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			col := (x - b.Min.X) * cols / b.Dx()
+			g := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+			sums[row*cols+col] += float64(g.Y)
+			counts[row*cols+col]++
+		}
+	}
+	cell := func(row, col int) float64 {
+		i := row*cols + col
+		if counts[i] == 0 {
+			return 0
+		}
+		return sums[i] / float64(counts[i])
+	}
+
+	h := Hash{bits: make([]uint64, (width*height+63)/64), n: width * height}
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			if cell(row, col)-cell(row, col+1) > minDiff {
+				i := row*width + col
+				h.bits[i/64] |= 1 << (i % 64)
+			}
+		}
+	}
+	return h
+}
+
+// File decodifica la imagen de path y devuelve su DHash con la rejilla por
+// defecto.
+func File(path string) (Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Hash{}, fmt.Errorf("no se pudo abrir la imagen %s: %w", path, err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return Hash{}, fmt.Errorf("no se pudo decodificar la imagen %s: %w", path, err)
+	}
+	return DHash(img, DefaultWidth, DefaultHeight), nil
+}
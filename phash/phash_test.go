@@ -0,0 +1,58 @@
+package phash
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// strip dibuja una tira de 960x90 con fondo oscuro y "letras" claras de 20
+// píxeles de ancho en las columnas indicadas. noise añade un ruido de +-noise
+// niveles a cada píxel, como el de la compresión JPEG.
+func strip(letters []int, noise int, seed int64) image.Image {
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewGray(image.Rect(0, 0, 960, 90))
+	for y := 0; y < 90; y++ {
+		for x := 0; x < 960; x++ {
+			v := 30
+			for _, l := range letters {
+				if x >= l && x < l+20 && y >= 25 && y < 65 {
+					v = 230
+				}
+			}
+			if noise > 0 {
+				v += rng.Intn(2*noise+1) - noise
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	return img
+}
+
+func TestDistance(t *testing.T) {
+	line := []int{200, 240, 280, 340, 380, 420, 480, 520}
+	other := []int{200, 260, 300, 360, 400, 460, 500, 560}
+
+	a := DHash(strip(line, 0, 1), DefaultWidth, DefaultHeight)
+	if a.Len() != DefaultWidth*DefaultHeight {
+		t.Fatalf("Len = %d, se esperaba %d", a.Len(), DefaultWidth*DefaultHeight)
+	}
+	if d := Distance(a, a); d != 0 {
+		t.Errorf("distancia consigo mismo = %g, se esperaba 0", d)
+	}
+
+	noisy := DHash(strip(line, 3, 2), DefaultWidth, DefaultHeight)
+	if d := Distance(a, noisy); d > 0.05 {
+		t.Errorf("distancia con ruido = %g, se esperaba <= 0.05", d)
+	}
+
+	different := DHash(strip(other, 0, 3), DefaultWidth, DefaultHeight)
+	if d := Distance(a, different); d <= 0.05 {
+		t.Errorf("distancia entre frases distintas = %g, se esperaba > 0.05", d)
+	}
+
+	if d := Distance(a, DHash(strip(line, 0, 1), 8, 8)); d != 1 {
+		t.Errorf("distancia entre tamaños distintos = %g, se esperaba 1", d)
+	}
+}
@@ -0,0 +1,243 @@
+// Package preprocess prepara las imágenes antes del OCR: recorte, escala de
+// grises, binarización, inversión, ampliación y margen. El texto de anime
+// (de colores y con borde) se reconoce mucho mejor en negro sobre blanco.
+package preprocess
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "github.com/yoshi70001/googleDocsOCR/imagecodecs"
+)
+
+// Options describe los pasos a aplicar, en este orden: Crop, Grayscale,
+// Threshold, Invert, Scale y Padding. Con el valor cero no se hace nada.
+type Options struct {
+	// Crop es la región a conservar, en píxeles. Vacía para no recortar.
+	Crop image.Rectangle
+	// Grayscale convierte la imagen a escala de grises.
+	Grayscale bool
+	// Threshold, entre 1 y 255, binariza la imagen: los píxeles con
+	// luminancia mayor o igual pasan a blanco y el resto a negro. Implica
+	// Grayscale. 0 no binariza.
+	Threshold int
+	// Invert invierte los colores (texto blanco sobre oscuro pasa a negro
+	// sobre claro).
+	Invert bool
+	// Scale amplía la imagen con interpolación bilineal. 0 o 1 no la cambian.
+	Scale float64
+	// Padding añade un margen de este número de píxeles con el color de la
+	// esquina superior izquierda, que suele ser el fondo.
+	Padding int
+}
+
+// Enabled indica si hay algún paso que aplicar.
+func (o Options) Enabled() bool {
+	return !o.Crop.Empty() || o.Grayscale || o.Threshold > 0 || o.Invert || o.Scale > 1 || o.Padding > 0
+}
+
+// Validate comprueba que los valores están dentro de rango.
+func (o Options) Validate() error {
+	if o.Threshold < 0 || o.Threshold > 255 {
+		return fmt.Errorf("el umbral debe estar entre 0 y 255 (valor: %d)", o.Threshold)
+	}
+	// 0 equivale a 1 (sin cambio); no se admite reducir la imagen.
+	if o.Scale != 0 && (o.Scale < 1 || o.Scale > 8) {
+		return fmt.Errorf("la ampliación debe ser 0 o estar entre 1 y 8 (valor: %g)", o.Scale)
+	}
+	if o.Padding < 0 {
+		return fmt.Errorf("el margen no puede ser negativo (valor: %d)", o.Padding)
+	}
+	return nil
+}
+
+// ParseCrop interpreta una región "x,y,ancho,alto" en píxeles.
+func ParseCrop(s string) (image.Rectangle, error) {
+	if strings.TrimSpace(s) == "" {
+		return image.Rectangle{}, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("recorte inválido %q: se esperaba x,y,ancho,alto", s)
+	}
+	var v [4]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 {
+			return image.Rectangle{}, fmt.Errorf("recorte inválido %q: %q no es un número de píxeles", s, part)
+		}
+		v[i] = n
+	}
+	if v[2] == 0 || v[3] == 0 {
+		return image.Rectangle{}, fmt.Errorf("recorte inválido %q: el ancho y el alto deben ser mayores que cero", s)
+	}
+	return image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]), nil
+}
+
+// Process aplica los pasos de o a la imagen y devuelve una nueva.
+func Process(src image.Image, o Options) (image.Image, error) {
+	img := src
+	if !o.Crop.Empty() {
+		region := o.Crop.Add(src.Bounds().Min).Intersect(src.Bounds())
+		if region.Empty() {
+			return nil, fmt.Errorf("el recorte %v queda fuera de la imagen (%dx%d)", o.Crop, src.Bounds().Dx(), src.Bounds().Dy())
+		}
+		img = copyRegion(src, region)
+	}
+
+	if o.Grayscale || o.Threshold > 0 {
+		img = toGray(img, o.Threshold)
+	}
+	if o.Invert {
+		img = invert(img)
+	}
+	if o.Scale > 1 {
+		img = scaleBilinear(img, o.Scale)
+	}
+	if o.Padding > 0 {
+		img = pad(img, o.Padding)
+	}
+	return img, nil
+}
+
+// ProcessFile decodifica la imagen src (en cualquiera de los formatos de
+// imagecodecs), la procesa y la guarda como PNG en dstDir con el mismo nombre
+// base. Devuelve la ruta del resultado.
+func ProcessFile(src, dstDir string, o Options) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo abrir la imagen %s: %w", src, err)
+	}
+	img, _, err := image.Decode(in)
+	in.Close()
+	if err != nil {
+		return "", fmt.Errorf("no se pudo decodificar la imagen %s: %w", src, err)
+	}
+
+	processed, err := Process(img, o)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", src, err)
+	}
+
+	base := filepath.Base(src)
+	dst := filepath.Join(dstDir, strings.TrimSuffix(base, filepath.Ext(base))+".png")
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo crear la imagen procesada: %w", err)
+	}
+	if err := png.Encode(out, processed); err != nil {
+		out.Close()
+		return "", fmt.Errorf("no se pudo guardar la imagen procesada %s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("no se pudo guardar la imagen procesada %s: %w", dst, err)
+	}
+	return dst, nil
+}
+
+// copyRegion copia una región de la imagen a una RGBA nueva con origen en (0, 0).
+func copyRegion(src image.Image, region image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, region.Min, draw.Src)
+	return dst
+}
+
+// toGray convierte a escala de grises y, si threshold > 0, binariza.
+func toGray(src image.Image, threshold int) *image.Gray {
+	b := src.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g := color.GrayModel.Convert(src.At(x, y)).(color.Gray)
+			if threshold > 0 {
+				if int(g.Y) >= threshold {
+					g.Y = 255
+				} else {
+					g.Y = 0
+				}
+			}
+			dst.SetGray(x-b.Min.X, y-b.Min.Y, g)
+		}
+	}
+	return dst
+}
+
+// invert invierte los colores conservando la transparencia.
+func invert(src image.Image) image.Image {
+	b := src.Bounds()
+	if gray, ok := src.(*image.Gray); ok {
+		dst := image.NewGray(b)
+		for i, v := range gray.Pix {
+			dst.Pix[i] = 255 - v
+		}
+		return dst
+	}
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			dst.SetNRGBA(x, y, color.NRGBA{R: 255 - c.R, G: 255 - c.G, B: 255 - c.B, A: c.A})
+		}
+	}
+	return dst
+}
+
+// scaleBilinear amplía la imagen por el factor dado con interpolación bilineal.
+func scaleBilinear(src image.Image, factor float64) image.Image {
+	b := src.Bounds()
+	w := int(math.Round(float64(b.Dx()) * factor))
+	h := int(math.Round(float64(b.Dy()) * factor))
+	_, gray := src.(*image.Gray)
+
+	var dst draw.Image
+	if gray {
+		dst = image.NewGray(image.Rect(0, 0, w, h))
+	} else {
+		dst = image.NewNRGBA(image.Rect(0, 0, w, h))
+	}
+
+	at := func(x, y int) [4]float64 {
+		x = min(max(x, 0), b.Dx()-1)
+		y = min(max(y, 0), b.Dy()-1)
+		c := color.NRGBAModel.Convert(src.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+		return [4]float64{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+	}
+	for y := 0; y < h; y++ {
+		sy := (float64(y)+0.5)/factor - 0.5
+		y0 := int(math.Floor(sy))
+		fy := sy - float64(y0)
+		for x := 0; x < w; x++ {
+			sx := (float64(x)+0.5)/factor - 0.5
+			x0 := int(math.Floor(sx))
+			fx := sx - float64(x0)
+
+			c00, c10, c01, c11 := at(x0, y0), at(x0+1, y0), at(x0, y0+1), at(x0+1, y0+1)
+			var v [4]uint8
+			for i := range v {
+				top := c00[i]*(1-fx) + c10[i]*fx
+				bottom := c01[i]*(1-fx) + c11[i]*fx
+				v[i] = uint8(math.Round(top*(1-fy) + bottom*fy))
+			}
+			dst.Set(x, y, color.NRGBA{R: v[0], G: v[1], B: v[2], A: v[3]})
+		}
+	}
+	return dst
+}
+
+// pad añade un margen del color de la esquina superior izquierda.
+func pad(src image.Image, padding int) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx()+2*padding, b.Dy()+2*padding))
+	background := image.NewUniform(src.At(b.Min.X, b.Min.Y))
+	draw.Draw(dst, dst.Bounds(), background, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(padding, padding, padding+b.Dx(), padding+b.Dy()), src, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,135 @@
+package preprocess
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// subtitleImage dibuja un fondo oscuro de 10x4 con una barra blanca de
+// "texto" en las columnas 4 y 5.
+func subtitleImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 10; x++ {
+			c := color.RGBA{R: 20, G: 30, B: 60, A: 255}
+			if x == 4 || x == 5 {
+				c = color.RGBA{R: 250, G: 240, B: 230, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestProcessPipeline(t *testing.T) {
+	opts := Options{
+		Crop:      image.Rect(2, 0, 8, 4),
+		Threshold: 128,
+		Invert:    true,
+		Scale:     2,
+		Padding:   3,
+	}
+	out, err := Process(subtitleImage(), opts)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	// 6x4 recortado, x2 = 12x8, más 3 píxeles por lado = 18x14.
+	if got := out.Bounds(); got.Dx() != 18 || got.Dy() != 14 {
+		t.Fatalf("tamaño = %dx%d, se esperaba 18x14", got.Dx(), got.Dy())
+	}
+	gray := func(x, y int) uint8 {
+		return color.GrayModel.Convert(out.At(x, y)).(color.Gray).Y
+	}
+	// Fondo (y margen) blanco tras invertir; el texto queda negro.
+	if v := gray(0, 0); v != 255 {
+		t.Errorf("margen = %d, se esperaba 255", v)
+	}
+	if v := gray(3+1, 3+1); v != 255 {
+		t.Errorf("fondo = %d, se esperaba 255", v)
+	}
+	// El texto estaba en las columnas 2 y 3 del recorte: 4 a 7 tras ampliar.
+	if v := gray(3+5, 3+2); v != 0 {
+		t.Errorf("texto = %d, se esperaba 0", v)
+	}
+}
+
+func TestProcessCropOutside(t *testing.T) {
+	if _, err := Process(subtitleImage(), Options{Crop: image.Rect(20, 20, 30, 30)}); err == nil {
+		t.Error("se esperaba un error con un recorte fuera de la imagen")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, scale := range []float64{0, 1, 2.5, 8} {
+		if err := (Options{Scale: scale}).Validate(); err != nil {
+			t.Errorf("Validate(Scale: %g): %v", scale, err)
+		}
+	}
+	for _, scale := range []float64{-1, 0.5, 8.5} {
+		if err := (Options{Scale: scale}).Validate(); err == nil {
+			t.Errorf("Validate(Scale: %g): se esperaba un error", scale)
+		}
+	}
+	for _, o := range []Options{{Threshold: -1}, {Threshold: 256}, {Padding: -1}} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%+v): se esperaba un error", o)
+		}
+	}
+}
+
+func TestParseCrop(t *testing.T) {
+	r, err := ParseCrop("10, 20,300,40")
+	if err != nil {
+		t.Fatalf("ParseCrop: %v", err)
+	}
+	if want := image.Rect(10, 20, 310, 60); r != want {
+		t.Errorf("ParseCrop = %v, se esperaba %v", r, want)
+	}
+	for _, bad := range []string{"1,2,3", "a,b,c,d", "0,0,0,10", "-1,0,5,5"} {
+		if _, err := ParseCrop(bad); err == nil {
+			t.Errorf("ParseCrop(%q): se esperaba un error", bad)
+		}
+	}
+}
+
+func TestProcessFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "0_00_01_000__0_00_02_000.png")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, subtitleImage()); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	outDir := filepath.Join(dir, "prep")
+	if err := os.Mkdir(outDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dst, err := ProcessFile(src, outDir, Options{Grayscale: true})
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	if want := filepath.Join(outDir, "0_00_01_000__0_00_02_000.png"); dst != want {
+		t.Errorf("ruta = %s, se esperaba %s", dst, want)
+	}
+	in, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	img, err := png.Decode(in)
+	if err != nil {
+		t.Fatalf("no se pudo leer la imagen procesada: %v", err)
+	}
+	if _, ok := img.(*image.Gray); !ok {
+		t.Errorf("la imagen procesada es %T, se esperaba *image.Gray", img)
+	}
+}
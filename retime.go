@@ -0,0 +1,64 @@
+// retime.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yoshi70001/googleDocsOCR/srtbuilder"
+)
+
+// addRetimeFlags registra los flags de desplazamiento y reescalado, comunes
+// al flujo principal y al subcomando "retime".
+func addRetimeFlags(fs *flag.FlagSet, opts *srtbuilder.RetimeOptions) {
+	fs.DurationVar(&opts.Offset, "offset", 0, "Desplazamiento que se suma a todos los tiempos, p. ej. 1.5s o -200ms")
+	fs.Float64Var(&opts.SourceFPS, "source-fps", 0, "Fps del vídeo del que salieron los tiempos (junto con -target-fps)")
+	fs.Float64Var(&opts.TargetFPS, "target-fps", 0, "Fps del vídeo al que se quieren ajustar los tiempos (junto con -source-fps)")
+}
+
+// runRetime implementa el subcomando "retime": aplica un desplazamiento y/o un
+// cambio de fps a un archivo SRT o WebVTT ya existente.
+func runRetime(args []string) {
+	fs := flag.NewFlagSet("retime", flag.ExitOnError)
+	var opts srtbuilder.RetimeOptions
+	addRetimeFlags(fs, &opts)
+	output := fs.String("o", "", "Archivo de salida (por defecto, <entrada>_retimed con la misma extensión)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: googleDocsOCR retime [flags] archivo.srt|archivo.vtt")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := opts.Validate(); err != nil {
+		log.Fatalf("Opciones de tiempo inválidas: %v", err)
+	}
+
+	input := fs.Arg(0)
+	blocks, err := srtbuilder.ReadFile(input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("✓ Leídos %d subtítulos de '%s'.", len(blocks), input)
+
+	ext := filepath.Ext(input)
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(input, ext) + "_retimed" + ext
+	}
+
+	srtbuilder.SortByStart(blocks)
+	blocks = srtbuilder.Renumber(srtbuilder.Retime(blocks, opts))
+	format := strings.TrimPrefix(strings.ToLower(ext), ".")
+	if err := srtbuilder.WriteFile(outputPath, blocks, srtbuilder.Options{Format: format}); err != nil {
+		log.Fatalf("Fallo al escribir '%s': %v", outputPath, err)
+	}
+	log.Printf("✓ Subtítulo guardado en '%s'.", outputPath)
+}
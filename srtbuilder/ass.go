@@ -0,0 +1,154 @@
+// srtbuilder/ass.go
+package srtbuilder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FormatASS es el formato Advanced SubStation Alpha (Aegisub).
+const FormatASS = "ass"
+
+// ASSStyle es el estilo por defecto que se escribe en [V4+ Styles].
+type ASSStyle struct {
+	Name     string
+	FontName string
+	FontSize int
+	// Colores en formato ASS &HAABBGGRR.
+	PrimaryColour string
+	OutlineColour string
+	BackColour    string
+	Bold          bool
+	Italic        bool
+	Outline       float64
+	Shadow        float64
+	// Alignment sigue el teclado numérico: 2 es abajo al centro.
+	Alignment int
+	MarginL   int
+	MarginR   int
+	MarginV   int
+}
+
+// DefaultASSStyle es un estilo de subtítulo de anime legible a 1080p.
+var DefaultASSStyle = ASSStyle{
+	Name:          "Default",
+	FontName:      "Arial",
+	FontSize:      64,
+	PrimaryColour: "&H00FFFFFF",
+	OutlineColour: "&H00000000",
+	BackColour:    "&H80000000",
+	Outline:       3,
+	Shadow:        1,
+	Alignment:     2,
+	MarginL:       40,
+	MarginR:       40,
+	MarginV:       50,
+}
+
+// assBool convierte un booleano al formato de ASS (-1 verdadero, 0 falso).
+func assBool(b bool) int {
+	if b {
+		return -1
+	}
+	return 0
+}
+
+// assHeader genera [Script Info] y [V4+ Styles] para el estilo dado.
+func assHeader(style ASSStyle) string {
+	return fmt.Sprintf(`[Script Info]
+; Generado por googleDocsOCR
+ScriptType: v4.00+
+PlayResX: 1920
+PlayResY: 1080
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: %s,%s,%d,%s,&H000000FF,%s,%s,%d,%d,0,0,100,100,0,0,1,%s,%s,%d,%d,%d,%d,1
+
+`,
+		style.Name, style.FontName, style.FontSize,
+		style.PrimaryColour, style.OutlineColour, style.BackColour,
+		assBool(style.Bold), assBool(style.Italic),
+		strconv.FormatFloat(style.Outline, 'f', -1, 64),
+		strconv.FormatFloat(style.Shadow, 'f', -1, 64),
+		style.Alignment, style.MarginL, style.MarginR, style.MarginV)
+}
+
+// loadASSTemplate lee un archivo .ass (por ejemplo exportado de Aegisub) y
+// devuelve todo lo anterior a [Events] para usarlo como cabecera, junto con el
+// nombre del primer estilo definido, que será el de los diálogos.
+func loadASSTemplate(path string) (header, styleName string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", fmt.Errorf("no se pudo abrir la plantilla ASS '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.EqualFold(trimmed, "[Events]") {
+			break
+		}
+		if styleName == "" && strings.HasPrefix(trimmed, "Style:") {
+			fields := strings.SplitN(strings.TrimPrefix(trimmed, "Style:"), ",", 2)
+			styleName = strings.TrimSpace(fields[0])
+		}
+		b.WriteString(strings.TrimPrefix(line, "\ufeff"))
+		b.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", fmt.Errorf("no se pudo leer la plantilla ASS '%s': %w", path, err)
+	}
+	if styleName == "" {
+		return "", "", fmt.Errorf("la plantilla ASS '%s' no define ningún estilo (línea 'Style:')", path)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n\n", styleName, nil
+}
+
+// writeASS escribe los bloques como un script ASS. Si templatePath no está
+// vacío, su cabecera y su primer estilo sustituyen al estilo indicado.
+func writeASS(w io.Writer, blocks []SubtitleBlock, style ASSStyle, templatePath string) error {
+	header, styleName := assHeader(style), style.Name
+	if templatePath != "" {
+		var err error
+		header, styleName, err = loadASSTemplate(templatePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, header+"[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n"); err != nil {
+		return fmt.Errorf("error al escribir en el archivo ASS: %w", err)
+	}
+
+	for _, block := range blocks {
+		dialogue := fmt.Sprintf("Dialogue: 0,%s,%s,%s,,0,0,0,,%s\n",
+			formatASSTime(block.Start), formatASSTime(block.End), styleName, assText(blockText(block)))
+		if _, err := io.WriteString(w, dialogue); err != nil {
+			return fmt.Errorf("error al escribir en el archivo ASS: %w", err)
+		}
+	}
+	return nil
+}
+
+// assTagReplacer traduce las etiquetas de formato de SRT a override tags de ASS.
+var assTagReplacer = strings.NewReplacer(
+	"<i>", `{\i1}`, "</i>", `{\i0}`,
+	"<b>", `{\b1}`, "</b>", `{\b0}`,
+	"\r\n", `\N`, "\n", `\N`,
+)
+
+// assText adapta el texto a una línea Dialogue: los saltos de línea pasan a \N
+// y las etiquetas <i>/<b> a sus override tags.
+func assText(text string) string {
+	return assTagReplacer.Replace(text)
+}
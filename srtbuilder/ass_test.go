@@ -0,0 +1,106 @@
+package srtbuilder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteASS(t *testing.T) {
+	blocks := []SubtitleBlock{
+		{1, at("0:00:01,004"), at("0:00:02,995"), "<i>Hola</i>\n<b>mundo</b>"},
+		{2, at("1:02:03,456"), at("1:02:04,999"), "Adiós"},
+	}
+	var sb strings.Builder
+	if err := writeASS(&sb, blocks, DefaultASSStyle, ""); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"[Script Info]\n",
+		"ScriptType: v4.00+\n",
+		"[V4+ Styles]\n",
+		"Style: Default,Arial,64,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,40,40,50,1\n",
+		"[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n",
+		`Dialogue: 0,0:00:01.00,0:00:03.00,Default,,0,0,0,,{\i1}Hola{\i0}\N{\b1}mundo{\b0}` + "\n",
+		"Dialogue: 0,1:02:03.46,1:02:05.00,Default,,0,0,0,,Adiós\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("falta %q en la salida:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatASSTimeRounding(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0:00:00,000", "0:00:00.00"},
+		{"0:00:00,004", "0:00:00.00"},
+		{"0:00:00,005", "0:00:00.01"},
+		{"1:02:03,456", "1:02:03.46"},
+		{"0:00:59,995", "0:01:00.00"},
+		{"0:59:59,999", "1:00:00.00"},
+	}
+	for _, tt := range tests {
+		if got := formatASSTime(at(tt.in)); got != tt.want {
+			t.Errorf("formatASSTime(%s) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+	if got := formatASSTime(-at("0:00:01,000")); got != "0:00:00.00" {
+		t.Errorf("formatASSTime(negativo) = %q, se esperaba 0:00:00.00", got)
+	}
+}
+
+func TestLoadASSTemplate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write("plantilla.ass", "\ufeff[Script Info]\r\nTitle: Prueba\r\n\r\n"+
+		"[V4+ Styles]\r\n"+
+		"Format: Name, Fontname, Fontsize\r\n"+
+		"Style: Carteles,Verdana,48\r\n"+
+		"Style: Otro,Arial,40\r\n\r\n"+
+		"[Events]\r\n"+
+		"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\r\n"+
+		"Dialogue: 0,0:00:00.00,0:00:01.00,Otro,,0,0,0,,Viejo\r\n")
+	header, style, err := loadASSTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if style != "Carteles" {
+		t.Errorf("estilo = %q, se esperaba el primero (Carteles)", style)
+	}
+	if strings.ContainsAny(header, "\r\ufeff") {
+		t.Errorf("la cabecera conserva CR o BOM: %q", header)
+	}
+	if !strings.HasPrefix(header, "[Script Info]\n") || !strings.HasSuffix(header, "Style: Otro,Arial,40\n\n") {
+		t.Errorf("cabecera inesperada: %q", header)
+	}
+	if strings.Contains(header, "[Events]") || strings.Contains(header, "Viejo") {
+		t.Errorf("la cabecera incluye los eventos de la plantilla: %q", header)
+	}
+
+	var sb strings.Builder
+	blocks := []SubtitleBlock{{1, at("0:00:01,000"), at("0:00:02,000"), "Hola"}}
+	if err := writeASS(&sb, blocks, DefaultASSStyle, path); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Dialogue: 0,0:00:01.00,0:00:02.00,Carteles,,0,0,0,,Hola\n"; !strings.Contains(sb.String(), want) {
+		t.Errorf("falta %q en la salida:\n%s", want, sb.String())
+	}
+
+	empty := write("sin_estilo.ass", "[Script Info]\nTitle: Prueba\n\n[Events]\n")
+	if _, _, err := loadASSTemplate(empty); err == nil || !strings.Contains(err.Error(), "no define ningún estilo") {
+		t.Errorf("error = %v, se esperaba que la plantilla sin estilo fallara", err)
+	}
+}
@@ -0,0 +1,171 @@
+// srtbuilder/cleaner.go
+package srtbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CleanRule es un paso de la limpieza del texto de OCR. Recibe las líneas del
+// texto y el título del documento (el nombre de la imagen sin extensión) y
+// devuelve las líneas resultantes.
+type CleanRule func(lines []string, title string) []string
+
+// Nombres de las reglas de limpieza.
+const (
+	// RuleDocsArtifacts quita el BOM, los caracteres invisibles, las líneas
+	// separadoras y el título del documento que añade Google Docs.
+	RuleDocsArtifacts = "docs-artifacts"
+	// RuleFullWidth pasa letras, números, signos y espacios de ancho completo
+	// a su forma normal.
+	RuleFullWidth = "fullwidth"
+	// RulePunctuationOnly quita las líneas sin letras ni números (restos de
+	// OCR como "." o "|"), salvo las de notas musicales.
+	RulePunctuationOnly = "punctuation-only"
+	// RuleWhitespace colapsa los espacios, recorta cada línea y quita las
+	// líneas vacías, que romperían el bloque SRT.
+	RuleWhitespace = "whitespace"
+)
+
+// DefaultCleanRules es el orden de reglas que se aplica si no se indica otro.
+var DefaultCleanRules = []string{RuleDocsArtifacts, RuleFullWidth, RulePunctuationOnly, RuleWhitespace}
+
+// cleanRules son las reglas disponibles, por nombre.
+var cleanRules = map[string]CleanRule{
+	RuleDocsArtifacts:   stripDocsArtifacts,
+	RuleFullWidth:       mapLines(normalizeFullWidth),
+	RulePunctuationOnly: dropPunctuationOnly,
+	RuleWhitespace:      normalizeWhitespace,
+}
+
+// CleanRuleNames devuelve los nombres de todas las reglas, ordenados.
+func CleanRuleNames() []string {
+	names := make([]string, 0, len(cleanRules))
+	for name := range cleanRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Cleaner limpia el texto de OCR aplicando una lista de reglas en orden.
+type Cleaner struct {
+	rules []CleanRule
+}
+
+// NewCleaner crea un limpiador con las reglas indicadas por nombre. Una lista
+// vacía da un limpiador que solo separa líneas y recorta el texto.
+func NewCleaner(names []string) (*Cleaner, error) {
+	c := &Cleaner{}
+	for _, name := range names {
+		rule, ok := cleanRules[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("regla de limpieza desconocida: %q (soportadas: %s)", name, strings.Join(CleanRuleNames(), ", "))
+		}
+		c.rules = append(c.rules, rule)
+	}
+	return c, nil
+}
+
+// DefaultCleaner devuelve un limpiador con DefaultCleanRules.
+func DefaultCleaner() *Cleaner {
+	c, err := NewCleaner(DefaultCleanRules)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Clean aplica las reglas al texto. title es el nombre del documento de
+// Google Docs, que coincide con el de la imagen sin extensión.
+func (c *Cleaner) Clean(rawText, title string) string {
+	text := strings.ReplaceAll(rawText, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for _, rule := range c.rules {
+		lines = rule(lines, title)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// mapLines convierte una transformación de una línea en una regla.
+func mapLines(fn func(string) string) CleanRule {
+	return func(lines []string, _ string) []string {
+		out := make([]string, len(lines))
+		for i, line := range lines {
+			out[i] = fn(line)
+		}
+		return out
+	}
+}
+
+// invisibleReplacer quita el BOM y los caracteres de anchura cero.
+var invisibleReplacer = strings.NewReplacer("\ufeff", "", "\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "")
+
+// separatorRe reconoce las líneas separadoras (reglas horizontales) que Docs
+// exporta como una fila de guiones bajos u otros signos.
+var separatorRe = regexp.MustCompile(`^[\s_\-\x{2010}-\x{2015}=*~·•]{3,}$`)
+
+func stripDocsArtifacts(lines []string, title string) []string {
+	title = strings.TrimSpace(title)
+	var out []string
+	for _, line := range lines {
+		line = invisibleReplacer.Replace(line)
+		trimmed := strings.TrimSpace(line)
+		if separatorRe.MatchString(trimmed) {
+			continue
+		}
+		if title != "" && strings.EqualFold(trimmed, title) {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// normalizeFullWidth pasa los caracteres ASCII de ancho completo (U+FF01 a
+// U+FF5E) y el espacio ideográfico a su forma normal. El resto del texto
+// japonés no cambia.
+func normalizeFullWidth(line string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '\uff01' && r <= '\uff5e':
+			return r - 0xfee0
+		case r == '\u3000':
+			return ' '
+		}
+		return r
+	}, line)
+}
+
+// musicNotes se conservan aunque vayan solas: marcan una canción.
+const musicNotes = "♪♫♬♩"
+
+func dropPunctuationOnly(lines []string, _ string) []string {
+	var out []string
+	for _, line := range lines {
+		keep := strings.TrimSpace(line) == "" || strings.ContainsAny(line, musicNotes)
+		for _, r := range line {
+			if keep {
+				break
+			}
+			keep = unicode.IsLetter(r) || unicode.IsDigit(r)
+		}
+		if keep {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func normalizeWhitespace(lines []string, _ string) []string {
+	var out []string
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package srtbuilder
+
+import "testing"
+
+func TestDefaultCleaner(t *testing.T) {
+	tests := []struct {
+		name, raw, title, want string
+	}{
+		{"cabecera de Docs", "\ufeff\r\n\r\nHola\r\n", "", "Hola"},
+		{"dos líneas reales", "¿Dónde estás?\n¡Aquí!", "", "¿Dónde estás?\n¡Aquí!"},
+		{"separador", "\ufeff\n________________\n\nPrimera\nSegunda\n", "", "Primera\nSegunda"},
+		{"título del documento", "0_00_01_000__0_00_02_000_0001\nHola", "0_00_01_000__0_00_02_000_0001", "Hola"},
+		{"solo puntuación", ".\nHola\n|\n- -\n", "", "Hola"},
+		{"notas musicales", "♪\nLa la la", "", "♪\nLa la la"},
+		{"ancho completo", "ＡＢＣ　１２３！？", "", "ABC 123!?"},
+		{"japonés intacto", "こんにちは、世界。", "", "こんにちは、世界。"},
+		{"espacios", "  Hola   \t mundo  \n\n\n  adiós ", "", "Hola mundo\nadiós"},
+		{"invisibles", "Ho\u200bla", "", "Hola"},
+		{"vacío", "\ufeff\n\n", "", ""},
+	}
+	cleaner := DefaultCleaner()
+	for _, tt := range tests {
+		if got := cleaner.Clean(tt.raw, tt.title); got != tt.want {
+			t.Errorf("%s: Clean(%q) = %q, se esperaba %q", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestCleanerRuleSelection(t *testing.T) {
+	raw := "ＡＢＣ\n.\nHola   mundo"
+
+	only, err := NewCleaner([]string{RuleFullWidth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := only.Clean(raw, ""), "ABC\n.\nHola   mundo"; got != want {
+		t.Errorf("solo %s: %q, se esperaba %q", RuleFullWidth, got, want)
+	}
+
+	none, err := NewCleaner(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := none.Clean(raw, ""); got != raw {
+		t.Errorf("sin reglas: %q, se esperaba el texto sin cambios", got)
+	}
+
+	if _, err := NewCleaner([]string{"no-existe"}); err == nil {
+		t.Error("NewCleaner aceptó una regla desconocida")
+	}
+}
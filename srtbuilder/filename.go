@@ -0,0 +1,208 @@
+// srtbuilder/filename.go
+package srtbuilder
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilenameParser extrae los tiempos de inicio y fin del nombre de un archivo
+// de texto (el de la imagen con extensión .txt).
+type FilenameParser func(filename string) (time.Duration, time.Duration, error)
+
+// Presets de nombres de archivo conocidos.
+const (
+	// PresetVideoSubFinder: "H_MM_SS_mmm__H_MM_SS_mmm[_sufijo]", el de VideoSubFinder.
+	PresetVideoSubFinder = "videosubfinder"
+	// PresetMilliseconds: "<inicio>_<fin>[_sufijo]" en milisegundos.
+	PresetMilliseconds = "milliseconds"
+)
+
+// presets relaciona cada preset con su parser.
+var presets = map[string]FilenameParser{
+	PresetVideoSubFinder: parseFilename,
+	PresetMilliseconds:   mustRegexParser(`^(?P<start>\d+)[_-](?P<end>\d+)(?:[_-].*)?$`),
+}
+
+// Presets devuelve los nombres de los presets disponibles, ordenados.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FilenameOptions elige cómo se obtienen los tiempos de los nombres de archivo.
+// Como mucho uno de Regex y FrameRegex puede estar puesto; si ninguno lo está
+// se usa Preset.
+type FilenameOptions struct {
+	// Preset es uno de Presets(). Vacío equivale a PresetVideoSubFinder.
+	Preset string
+	// Regex debe tener los grupos con nombre "start" y "end". Cada uno puede
+	// contener un tiempo con cuatro números (horas, minutos, segundos y
+	// milisegundos con cualquier separador), tres (sin horas) o uno solo
+	// (milisegundos).
+	Regex string
+	// FrameRegex debe tener el grupo con nombre "start" y opcionalmente "end",
+	// con números de fotograma que se convierten a tiempo con FPS. Sin "end",
+	// el bloque dura un fotograma.
+	FrameRegex string
+	FPS        float64
+}
+
+// Parser construye el FilenameParser descrito por las opciones.
+func (o FilenameOptions) Parser() (FilenameParser, error) {
+	switch {
+	case o.Regex != "" && o.FrameRegex != "":
+		return nil, fmt.Errorf("no se pueden usar a la vez una expresión de tiempos y una de fotogramas")
+	case o.Regex != "":
+		return newRegexParser(o.Regex)
+	case o.FrameRegex != "":
+		return newFrameParser(o.FrameRegex, o.FPS)
+	}
+
+	preset := o.Preset
+	if preset == "" {
+		preset = PresetVideoSubFinder
+	}
+	parser, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("preset de nombres desconocido: %q (soportados: %s)", preset, strings.Join(Presets(), ", "))
+	}
+	return parser, nil
+}
+
+// compileWithGroups compila la expresión y comprueba que tiene los grupos
+// con nombre obligatorios.
+func compileWithGroups(pattern string, required ...string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("expresión regular inválida: %v", err)
+	}
+	for _, group := range required {
+		if re.SubexpIndex(group) < 0 {
+			return nil, fmt.Errorf("la expresión %q no tiene el grupo con nombre (?P<%s>...)", pattern, group)
+		}
+	}
+	return re, nil
+}
+
+// matchGroups aplica la expresión al nombre sin extensión y devuelve el valor
+// de cada grupo con nombre.
+func matchGroups(re *regexp.Regexp, filename string) (map[string]string, error) {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	m := re.FindStringSubmatch(base)
+	if m == nil {
+		return nil, fmt.Errorf("el nombre de archivo no encaja con el patrón %q: %s", re, filename)
+	}
+	groups := make(map[string]string)
+	for i, name := range re.SubexpNames() {
+		if name != "" && m[i] != "" {
+			groups[name] = m[i]
+		}
+	}
+	return groups, nil
+}
+
+// digitRunRe separa los números de un tiempo con separadores arbitrarios.
+var digitRunRe = regexp.MustCompile(`\d+`)
+
+// parseLooseTime interpreta un tiempo con separadores arbitrarios: cuatro
+// números son H, MM, SS y mmm; tres, MM, SS y mmm; uno, milisegundos.
+func parseLooseTime(t string) (time.Duration, error) {
+	runs := digitRunRe.FindAllString(t, -1)
+	switch len(runs) {
+	case 1:
+		ms, err := strconv.ParseInt(runs[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("formato de tiempo inválido: %s: %v", t, err)
+		}
+		return time.Duration(ms) * time.Millisecond, nil
+	case 3:
+		return parseTimeFields(t, "0", runs[0], runs[1], runs[2])
+	case 4:
+		return parseTimeFields(t, runs[0], runs[1], runs[2], runs[3])
+	default:
+		return 0, fmt.Errorf("formato de tiempo inválido: %s", t)
+	}
+}
+
+// checkOrder devuelve un error si el fin es anterior al inicio.
+func checkOrder(filename string, start, end time.Duration) (time.Duration, time.Duration, error) {
+	if end < start {
+		return 0, 0, fmt.Errorf("el tiempo de fin es anterior al de inicio: %s", filename)
+	}
+	return start, end, nil
+}
+
+// newRegexParser crea un parser a partir de una expresión con los grupos
+// "start" y "end".
+func newRegexParser(pattern string) (FilenameParser, error) {
+	re, err := compileWithGroups(pattern, "start", "end")
+	if err != nil {
+		return nil, err
+	}
+	return func(filename string) (time.Duration, time.Duration, error) {
+		groups, err := matchGroups(re, filename)
+		if err != nil {
+			return 0, 0, err
+		}
+		start, err := parseLooseTime(groups["start"])
+		if err != nil {
+			return 0, 0, err
+		}
+		end, err := parseLooseTime(groups["end"])
+		if err != nil {
+			return 0, 0, err
+		}
+		return checkOrder(filename, start, end)
+	}, nil
+}
+
+// mustRegexParser es newRegexParser para los presets, que son fijos.
+func mustRegexParser(pattern string) FilenameParser {
+	parser, err := newRegexParser(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}
+
+// newFrameParser crea un parser a partir de una expresión con números de
+// fotograma en los grupos "start" y, opcionalmente, "end".
+func newFrameParser(pattern string, fps float64) (FilenameParser, error) {
+	if fps <= 0 {
+		return nil, fmt.Errorf("para usar números de fotograma hacen falta los fps del vídeo")
+	}
+	re, err := compileWithGroups(pattern, "start")
+	if err != nil {
+		return nil, err
+	}
+	frameTime := func(frame int64) time.Duration {
+		return time.Duration(float64(frame) / fps * float64(time.Second)).Round(time.Millisecond)
+	}
+	return func(filename string) (time.Duration, time.Duration, error) {
+		groups, err := matchGroups(re, filename)
+		if err != nil {
+			return 0, 0, err
+		}
+		startFrame, err := strconv.ParseInt(groups["start"], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("número de fotograma inválido %q: %s", groups["start"], filename)
+		}
+		endFrame := startFrame + 1
+		if value, ok := groups["end"]; ok {
+			if endFrame, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return 0, 0, fmt.Errorf("número de fotograma inválido %q: %s", value, filename)
+			}
+		}
+		return checkOrder(filename, frameTime(startFrame), frameTime(endFrame))
+	}, nil
+}
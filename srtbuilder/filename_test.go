@@ -0,0 +1,76 @@
+package srtbuilder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilenameParsers(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       FilenameOptions
+		filename   string
+		start, end time.Duration
+	}{
+		{"preset por defecto", FilenameOptions{}, "0_00_01_000__0_00_02_500_0001.txt", time.Second, 2500 * time.Millisecond},
+		{"preset milisegundos", FilenameOptions{Preset: PresetMilliseconds}, "1000_2500_img.txt", time.Second, 2500 * time.Millisecond},
+		{
+			"expresión con tiempos",
+			FilenameOptions{Regex: `^sub (?P<start>[\d.]+) - (?P<end>[\d.]+)$`},
+			"sub 00.00.01.000 - 00.00.02.500.txt", time.Second, 2500 * time.Millisecond,
+		},
+		{
+			"expresión sin horas",
+			FilenameOptions{Regex: `(?P<start>\d+m\d+s\d+)to(?P<end>\d+m\d+s\d+)`},
+			"01m02s003to01m03s000.txt", time.Minute + 2*time.Second + 3*time.Millisecond, time.Minute + 3*time.Second,
+		},
+		{
+			"fotogramas con fin",
+			FilenameOptions{FrameRegex: `frame_(?P<start>\d+)-(?P<end>\d+)`, FPS: 25},
+			"frame_25-50.txt", time.Second, 2 * time.Second,
+		},
+		{
+			"fotograma suelto",
+			FilenameOptions{FrameRegex: `^(?P<start>\d+)$`, FPS: 23.976},
+			"000024.txt", 1001 * time.Millisecond, 1043 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		parser, err := tt.opts.Parser()
+		if err != nil {
+			t.Errorf("%s: Parser: %v", tt.name, err)
+			continue
+		}
+		start, end, err := parser(tt.filename)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if start != tt.start || end != tt.end {
+			t.Errorf("%s: tiempos = %v, %v; se esperaba %v, %v", tt.name, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestFilenameOptionsErrors(t *testing.T) {
+	for name, opts := range map[string]FilenameOptions{
+		"preset desconocido":   {Preset: "otro"},
+		"regex inválida":       {Regex: `(`},
+		"falta el grupo end":   {Regex: `(?P<start>\d+)`},
+		"fotogramas sin fps":   {FrameRegex: `(?P<start>\d+)`},
+		"regex y fotogramas":   {Regex: `(?P<start>\d+)_(?P<end>\d+)`, FrameRegex: `(?P<start>\d+)`, FPS: 25},
+		"fotogramas sin grupo": {FrameRegex: `\d+`, FPS: 25},
+	} {
+		if _, err := opts.Parser(); err == nil {
+			t.Errorf("%s: Parser no devolvió error", name)
+		}
+	}
+
+	parser, _ := FilenameOptions{Preset: PresetMilliseconds}.Parser()
+	if _, _, err := parser("2500_1000.txt"); err == nil {
+		t.Error("se aceptó un fin anterior al inicio")
+	}
+	if _, _, err := parser("sin_numeros.txt"); err == nil {
+		t.Error("se aceptó un nombre que no encaja")
+	}
+}
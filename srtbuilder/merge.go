@@ -0,0 +1,104 @@
+// srtbuilder/merge.go
+package srtbuilder
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MergeOptions controla la fusión de subtítulos consecutivos repetidos.
+type MergeOptions struct {
+	// Enabled activa la fusión.
+	Enabled bool
+	// Threshold es la distancia de edición normalizada (0 a 1) por debajo de
+	// la cual dos textos se consideran el mismo. 0 solo fusiona textos idénticos.
+	Threshold float64
+	// MaxGap es el hueco máximo entre el fin de un bloque y el inicio del
+	// siguiente para considerarlos contiguos.
+	MaxGap time.Duration
+}
+
+// DefaultMergeOptions tolera las pequeñas diferencias de OCR entre fotogramas
+// de la misma línea que suele generar VideoSubFinder. La fusión está
+// desactivada: une bloques del subtítulo, así que hay que pedirla.
+var DefaultMergeOptions = MergeOptions{
+	Threshold: 0.2,
+	MaxGap:    250 * time.Millisecond,
+}
+
+// normalizeForCompare reduce un texto a lo que importa para compararlo:
+// minúsculas y espacios colapsados.
+func normalizeForCompare(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// editDistance calcula la distancia de Levenshtein entre a y b, por runas.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// textDistance devuelve la distancia de edición normalizada entre dos textos:
+// 0 si son iguales y 1 si no tienen nada en común.
+func textDistance(a, b string) float64 {
+	a, b = normalizeForCompare(a), normalizeForCompare(b)
+	if a == b {
+		return 0
+	}
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	return float64(editDistance(a, b)) / float64(longest)
+}
+
+// MergeDuplicates une los bloques adyacentes cuyo texto es el mismo (según
+// opts.Threshold) y que son contiguos en el tiempo (según opts.MaxGap). El
+// bloque resultante va del inicio del primero al fin del último y conserva el
+// texto del que más tiempo estuvo en pantalla, que suele ser la lectura de OCR
+// más limpia.
+func MergeDuplicates(blocks []SubtitleBlock, opts MergeOptions) []SubtitleBlock {
+	if !opts.Enabled || len(blocks) < 2 {
+		return blocks
+	}
+
+	merged := make([]SubtitleBlock, 0, len(blocks))
+	var bestDuration time.Duration
+	for _, block := range blocks {
+		duration := block.End - block.Start
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if block.Start-last.End <= opts.MaxGap && textDistance(last.Text, block.Text) <= opts.Threshold {
+				last.End = max(last.End, block.End)
+				if duration > bestDuration {
+					last.Text = block.Text
+					bestDuration = duration
+				}
+				continue
+			}
+		}
+
+		merged = append(merged, block)
+		bestDuration = duration
+	}
+
+	if removed := len(blocks) - len(merged); removed > 0 {
+		log.Printf("✓ Se fusionaron %d subtítulos repetidos (%d -> %d).", removed, len(blocks), len(merged))
+	}
+	return merged
+}
@@ -0,0 +1,74 @@
+package srtbuilder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// at convierte un tiempo "H:MM:SS,mmm" para escribir los casos de prueba.
+func at(t string) time.Duration {
+	clock, millis, _ := strings.Cut(t, ",")
+	parts := strings.Split(clock, ":")
+	d, err := parseTimeFields(t, parts[0], parts[1], parts[2], millis)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	blocks := []SubtitleBlock{
+		{1, at("0:00:01,000"), at("0:00:01,500"), "Hola, mundo"},
+		{2, at("0:00:01,500"), at("0:00:03,000"), "Hola, mundo."},
+		{3, at("0:00:03,100"), at("0:00:03,400"), "hola,  MUNDO"},
+		{4, at("0:00:05,000"), at("0:00:06,000"), "Hola, mundo"}, // hueco demasiado grande
+		{5, at("0:00:06,000"), at("0:00:07,000"), "Adiós"},
+	}
+
+	opts := DefaultMergeOptions
+	opts.Enabled = true
+	got := MergeDuplicates(blocks, opts)
+	want := []SubtitleBlock{
+		{1, at("0:00:01,000"), at("0:00:03,400"), "Hola, mundo."},
+		{4, at("0:00:05,000"), at("0:00:06,000"), "Hola, mundo"},
+		{5, at("0:00:06,000"), at("0:00:07,000"), "Adiós"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("MergeDuplicates = %+v, se esperaba %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bloque %d = %+v, se esperaba %+v", i, got[i], want[i])
+		}
+	}
+
+	exact := MergeOptions{Enabled: true, MaxGap: time.Second}
+	if got := MergeDuplicates(blocks[:2], exact); len(got) != 2 {
+		t.Errorf("con umbral 0 se fusionaron textos distintos: %+v", got)
+	}
+	if got := MergeDuplicates(blocks, MergeOptions{}); len(got) != len(blocks) {
+		t.Errorf("con la fusión desactivada se obtuvieron %d bloques", len(got))
+	}
+	if got := MergeDuplicates(blocks, DefaultMergeOptions); len(got) != len(blocks) {
+		t.Errorf("la fusión debe estar desactivada por defecto, se obtuvieron %d bloques", len(got))
+	}
+}
+
+func TestTextDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"abc", "abc", 0},
+		{"abc", "ABC ", 0},
+		{"abcd", "abce", 0.25},
+		{"", "abc", 1},
+		{"ñandú", "nandu", 0.4},
+	}
+	for _, tt := range tests {
+		if got := textDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("textDistance(%q, %q) = %g, se esperaba %g", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// srtbuilder/reader.go
+package srtbuilder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseCueTime convierte un tiempo "HH:MM:SS,mmm" (o con punto, como en
+// WebVTT, donde las horas son opcionales) en una duración.
+func parseCueTime(t string) (time.Duration, error) {
+	t = strings.TrimSpace(t)
+	clock, millis, ok := strings.Cut(strings.Replace(t, ".", ",", 1), ",")
+	parts := strings.Split(clock, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if !ok || len(parts) != 3 {
+		return 0, fmt.Errorf("formato de tiempo inválido: %s", t)
+	}
+	return parseTimeFields(t, parts[0], parts[1], parts[2], millis)
+}
+
+// parseTimingLine lee una línea "inicio --> fin". Lo que siga al tiempo de
+// fin (ajustes de posición) se ignora.
+func parseTimingLine(line string) (time.Duration, time.Duration, error) {
+	startText, rest, ok := strings.Cut(line, "-->")
+	if !ok {
+		return 0, 0, fmt.Errorf("se esperaba una línea de tiempos 'inicio --> fin': %q", line)
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("falta el tiempo de fin: %q", line)
+	}
+	start, err := parseCueTime(startText)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseCueTime(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// scanParagraphs divide el texto en grupos de líneas separados por líneas en
+// blanco y llama a fn con cada uno y el número de su primera línea. Quita el
+// BOM y los finales de línea CRLF.
+func scanParagraphs(r io.Reader, fn func(lines []string, lineNo int) error) error {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	lineNo, blockLine := 0, 0
+
+	flush := func() error {
+		if len(lines) == 0 {
+			return nil
+		}
+		err := fn(lines, blockLine)
+		lines = nil
+		return err
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if strings.TrimSpace(line) == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(lines) == 0 {
+			blockLine = lineNo
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("no se pudo leer el archivo de subtítulos: %w", err)
+	}
+	return flush()
+}
+
+// cueFromLines construye un bloque a partir de la línea de tiempos y las de
+// texto que la siguen.
+func cueFromLines(seq int, lines []string, lineNo int) (SubtitleBlock, error) {
+	start, end, err := parseTimingLine(lines[0])
+	if err != nil {
+		return SubtitleBlock{}, fmt.Errorf("línea %d: %w", lineNo, err)
+	}
+	return SubtitleBlock{
+		Sequence: seq,
+		Start:    start,
+		End:      end,
+		Text:     strings.Join(lines[1:], "\n"),
+	}, nil
+}
+
+// ReadSRT lee un archivo SRT. Acepta BOM, finales de línea CRLF y varias
+// líneas en blanco entre bloques.
+func ReadSRT(r io.Reader) ([]SubtitleBlock, error) {
+	var blocks []SubtitleBlock
+	err := scanParagraphs(r, func(lines []string, lineNo int) error {
+		// El número de secuencia es opcional en la práctica; si falta, la
+		// primera línea ya es la de tiempos.
+		seq := len(blocks) + 1
+		if !strings.Contains(lines[0], "-->") {
+			n, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+			if err != nil {
+				return fmt.Errorf("línea %d: número de secuencia inválido: %q", lineNo, lines[0])
+			}
+			seq = n
+			lines = lines[1:]
+			lineNo++
+		}
+		if len(lines) == 0 {
+			return fmt.Errorf("línea %d: bloque sin tiempos", lineNo)
+		}
+		block, err := cueFromLines(seq, lines, lineNo)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// ReadVTT lee un archivo WebVTT. Los bloques NOTE, STYLE y REGION se ignoran,
+// igual que los ajustes de cue; los identificadores numéricos se usan como
+// número de secuencia.
+func ReadVTT(r io.Reader) ([]SubtitleBlock, error) {
+	var blocks []SubtitleBlock
+	header := true
+	err := scanParagraphs(r, func(lines []string, lineNo int) error {
+		if header {
+			header = false
+			if first := lines[0]; first != "WEBVTT" && !strings.HasPrefix(first, "WEBVTT ") && !strings.HasPrefix(first, "WEBVTT\t") {
+				return fmt.Errorf("línea %d: falta la cabecera WEBVTT", lineNo)
+			}
+			return nil
+		}
+		if keyword, _, _ := strings.Cut(strings.TrimSpace(lines[0]), " "); keyword == "NOTE" || keyword == "STYLE" || keyword == "REGION" {
+			return nil
+		}
+
+		seq := len(blocks) + 1
+		if !strings.Contains(lines[0], "-->") {
+			// Identificador de cue.
+			if n, err := strconv.Atoi(strings.TrimSpace(lines[0])); err == nil {
+				seq = n
+			}
+			lines = lines[1:]
+			lineNo++
+		}
+		if len(lines) == 0 {
+			return fmt.Errorf("línea %d: cue sin tiempos", lineNo)
+		}
+		block, err := cueFromLines(seq, lines, lineNo)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if header {
+		return nil, fmt.Errorf("archivo WebVTT vacío")
+	}
+	return blocks, nil
+}
+
+// ReadFile lee un archivo de subtítulos SRT o WebVTT según su extensión.
+func ReadFile(path string) ([]SubtitleBlock, error) {
+	var read func(io.Reader) ([]SubtitleBlock, error)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case Extension(FormatSRT):
+		read = ReadSRT
+	case Extension(FormatVTT):
+		read = ReadVTT
+	default:
+		return nil, fmt.Errorf("formato de entrada no soportado: %q (soportados: .srt, .vtt)", ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir el subtítulo: %w", err)
+	}
+	defer f.Close()
+
+	blocks, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer '%s': %w", path, err)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,89 @@
+package srtbuilder
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSRT(t *testing.T) {
+	input := "\ufeff1\r\n00:00:01,000 --> 00:00:02,500\r\nHola\r\n<i>mundo</i>\r\n\r\n\r\n" +
+		"7\n0:01:00.250 --> 0:01:01,000 X1:10\nAdiós\n"
+	blocks, err := ReadSRT(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadSRT: %v", err)
+	}
+	want := []SubtitleBlock{
+		{1, time.Second, 2500 * time.Millisecond, "Hola\n<i>mundo</i>"},
+		{7, time.Minute + 250*time.Millisecond, time.Minute + time.Second, "Adiós"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("ReadSRT = %+v, se esperaba %+v", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("bloque %d = %+v, se esperaba %+v", i, blocks[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{
+		"x\n00:00:01,000 --> 00:00:02,000\nHola\n",
+		"1\n00:00:01,000 -> 00:00:02,000\nHola\n",
+		"1\n00:00:01,000 --> 00:61:02,000\nHola\n",
+	} {
+		if _, err := ReadSRT(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadSRT(%q) no devolvió error", bad)
+		}
+	}
+}
+
+func TestReadVTT(t *testing.T) {
+	input := "WEBVTT - generado\n\nNOTE un comentario\nde dos líneas\n\n" +
+		"STYLE\n::cue { color: yellow }\n\n" +
+		"3\n00:00:01.000 --> 00:00:02.500 line:85% align:center\n<i>Hola</i>\n\n" +
+		"intro\n01:00.250 --> 01:01.000\nAdiós\n"
+	blocks, err := ReadVTT(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadVTT: %v", err)
+	}
+	want := []SubtitleBlock{
+		{3, time.Second, 2500 * time.Millisecond, "<i>Hola</i>"},
+		{2, time.Minute + 250*time.Millisecond, time.Minute + time.Second, "Adiós"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("ReadVTT = %+v, se esperaba %+v", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("bloque %d = %+v, se esperaba %+v", i, blocks[i], want[i])
+		}
+	}
+
+	if _, err := ReadVTT(strings.NewReader("1\n00:00:01.000 --> 00:00:02.000\nHola\n")); err == nil {
+		t.Error("ReadVTT aceptó un archivo sin cabecera WEBVTT")
+	}
+}
+
+func TestReadWriteRoundTrip(t *testing.T) {
+	blocks := []SubtitleBlock{
+		{1, time.Second, 2500 * time.Millisecond, "Hola\n<i>mundo</i>"},
+		{2, time.Hour + 3*time.Second, time.Hour + 4*time.Second, "Adiós"},
+	}
+	for format, read := range map[string]func(r io.Reader) ([]SubtitleBlock, error){
+		FormatSRT: ReadSRT,
+		FormatVTT: ReadVTT,
+	} {
+		var b strings.Builder
+		if err := WriteSubtitles(&b, blocks, Options{Format: format}); err != nil {
+			t.Fatalf("%s: WriteSubtitles: %v", format, err)
+		}
+		got, err := read(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatalf("%s: lectura: %v", format, err)
+		}
+		if len(got) != len(blocks) || got[0] != blocks[0] || got[1] != blocks[1] {
+			t.Errorf("%s: ida y vuelta = %+v, se esperaba %+v", format, got, blocks)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// srtbuilder/retime.go
+package srtbuilder
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// RetimeOptions desplaza y reescala todos los tiempos de un subtítulo.
+type RetimeOptions struct {
+	// Offset se suma a todos los tiempos (puede ser negativo).
+	Offset time.Duration
+	// SourceFPS y TargetFPS convierten los tiempos de un vídeo a otro con
+	// distinta velocidad (p. ej. 23.976 -> 25 en las versiones PAL). Con
+	// cualquiera de los dos a cero no se reescala.
+	SourceFPS float64
+	TargetFPS float64
+}
+
+// Validate comprueba que los valores tienen sentido.
+func (o RetimeOptions) Validate() error {
+	if o.SourceFPS < 0 || o.TargetFPS < 0 {
+		return fmt.Errorf("los fps no pueden ser negativos (origen %g, destino %g)", o.SourceFPS, o.TargetFPS)
+	}
+	if (o.SourceFPS == 0) != (o.TargetFPS == 0) {
+		return fmt.Errorf("para reescalar hacen falta los fps de origen y de destino (origen %g, destino %g)", o.SourceFPS, o.TargetFPS)
+	}
+	return nil
+}
+
+// apply convierte un tiempo: primero reescala y luego desplaza. El resultado
+// puede ser negativo.
+func (o RetimeOptions) apply(t time.Duration) time.Duration {
+	if o.SourceFPS > 0 && o.TargetFPS > 0 {
+		t = time.Duration(float64(t) * o.SourceFPS / o.TargetFPS).Round(time.Millisecond)
+	}
+	return t + o.Offset
+}
+
+// Retime aplica el desplazamiento y el reescalado de opts a todos los bloques.
+// Los bloques que terminarían antes del inicio del vídeo se descartan y los
+// que lo cruzan empiezan en cero.
+func Retime(blocks []SubtitleBlock, opts RetimeOptions) []SubtitleBlock {
+	if opts.Offset == 0 && (opts.SourceFPS == 0 || opts.TargetFPS == 0 || opts.SourceFPS == opts.TargetFPS) {
+		return blocks
+	}
+	kept := blocks[:0]
+	for _, block := range blocks {
+		block.Start = max(opts.apply(block.Start), 0)
+		block.End = opts.apply(block.End)
+		if block.End <= 0 {
+			continue
+		}
+		kept = append(kept, block)
+	}
+	if opts.SourceFPS > 0 && opts.TargetFPS > 0 {
+		log.Printf("✓ Tiempos ajustados: fps %g -> %g, desplazamiento %s.", opts.SourceFPS, opts.TargetFPS, opts.Offset)
+	} else {
+		log.Printf("✓ Tiempos ajustados: desplazamiento %s.", opts.Offset)
+	}
+	if dropped := len(blocks) - len(kept); dropped > 0 {
+		log.Printf("    - Se descartaron %d bloques que terminaban antes del inicio del vídeo.", dropped)
+	}
+	return kept
+}
@@ -0,0 +1,48 @@
+package srtbuilder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetime(t *testing.T) {
+	blocks := []SubtitleBlock{
+		{1, 25 * time.Second, 50 * time.Second, "a"},
+		{2, 100 * time.Millisecond, time.Second, "b"},
+	}
+	got := Retime(blocks, RetimeOptions{Offset: -time.Second, SourceFPS: 25, TargetFPS: 23.976})
+	// 25 s a 25 fps son 625 fotogramas, que a 23.976 fps duran
+	// 26.068 s; menos el desplazamiento, 25.068 s.
+	if want := 25068 * time.Millisecond; got[0].Start != want {
+		t.Errorf("inicio = %v, se esperaba %v", got[0].Start, want)
+	}
+	if got[1].Start != 0 {
+		t.Errorf("un tiempo negativo debe quedar en cero, se obtuvo %v", got[1].Start)
+	}
+
+	// Con un desplazamiento negativo, lo que acaba antes del nuevo cero se
+	// descarta y lo que lo cruza empieza en cero.
+	blocks = []SubtitleBlock{
+		{1, at("0:00:00,500"), at("0:00:01,200"), "antes"},
+		{2, at("0:00:01,300"), at("0:00:02,000"), "justo en cero"},
+		{3, at("0:00:01,800"), at("0:00:02,500"), "cruza"},
+		{4, at("0:00:03,000"), at("0:00:04,000"), "después"},
+	}
+	got = Retime(blocks, RetimeOptions{Offset: -2 * time.Second})
+	want := []SubtitleBlock{
+		{3, 0, at("0:00:00,500"), "cruza"},
+		{4, at("0:00:01,000"), at("0:00:02,000"), "después"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Retime = %+v, se esperaba %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bloque %d = %+v, se esperaba %+v", i, got[i], want[i])
+		}
+	}
+
+	if err := (RetimeOptions{SourceFPS: 25}).Validate(); err == nil {
+		t.Error("Validate aceptó fps de origen sin fps de destino")
+	}
+}
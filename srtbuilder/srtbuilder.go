@@ -3,6 +3,8 @@ package srtbuilder
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
@@ -15,16 +17,37 @@ import (
 	"github.com/yoshi70001/googleDocsOCR/geminifix"
 )
 
-// SubtitleBlock representa una única entrada en un archivo SRT.
+// SubtitleBlock representa una única entrada en un archivo de subtítulos. Los
+// tiempos se cuentan desde el inicio del vídeo y cada escritor los formatea
+// a su manera.
 type SubtitleBlock struct {
-	Sequence  int
-	StartTime string
-	EndTime   string
-	Text      string
+	Sequence int
+	Start    time.Duration
+	End      time.Duration
+	Text     string
+}
+
+// CorrectionCache guarda las correcciones de Gemini ya obtenidas para que una
+// ejecución reanudada no vuelva a pagar por ellas. manifest.Manifest la implementa.
+type CorrectionCache interface {
+	CorrectedBatch(key string) ([]string, bool)
+	RecordBatch(key string, corrected []string) error
+}
+
+// batchKey identifica un lote por el modelo usado y su contenido exacto.
+func batchKey(model string, textBatch []string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	for _, text := range textBatch {
+		h.Write([]byte{0})
+		h.Write([]byte(text))
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // processBatch es una nueva función de ayuda para manejar la llamada a la IA.
-func processBatch(ctx context.Context, geminiClient *genai.Client, textBatch []string) []string {
+// El segundo valor indica si la corrección tuvo éxito o se devolvieron los textos originales.
+func processBatch(ctx context.Context, geminiClient *genai.Client, textBatch []string) ([]string, bool) {
 	log.Printf("  [AI] Enviando lote de %d textos a Gemini para corrección...", len(textBatch))
 
 	// Reintentos simples
@@ -34,81 +57,51 @@ func processBatch(ctx context.Context, geminiClient *genai.Client, textBatch []s
 		correctedBatch, geminiErr = geminifix.CorrectTextBatch(ctx, geminiClient, textBatch)
 		if geminiErr == nil {
 			log.Printf("  [✓] Lote procesado por Gemini.")
-			return correctedBatch // Éxito
+			return correctedBatch, true // Éxito
 		}
 		log.Printf("  [!] ADVERTENCIA: Intento %d de Gemini falló para el lote: %v. Reintentando...", attempt+1, geminiErr)
 		time.Sleep(2 * time.Second)
 	}
 
 	log.Printf("  [!] ERROR: Todos los intentos de Gemini fallaron para el lote. Usando textos originales.")
-	return textBatch // Devolvemos el lote original si todo falla
-}
-
-func cleanOcrText(rawText string) string {
-	// Dividimos el texto en un slice de líneas usando el salto de línea como separador.
-	lines := strings.Split(rawText, "\n")
-
-	// Medida de seguridad: Si hay 2 o menos líneas, significa que el texto es muy corto
-	// o está vacío. Devolverlo como está o devolver una cadena vacía es más seguro
-	// que intentar acceder a un índice que no existe (lo que causaría un pánico).
-	if len(lines) <= 2 {
-		// Podemos decidir qué hacer. Devolver la última línea (si existe) podría ser una opción.
-		// O simplemente devolver una cadena vacía si es probable que sea todo ruido.
-		// Vamos a unir todo lo que haya, que en este caso será poco o nada.
-		return strings.TrimSpace(rawText)
-	}
-
-	// Seleccionamos el sub-slice que va desde el tercer elemento (índice 2) hasta el final.
-	relevantLines := lines[2:]
-
-	// Unimos las líneas relevantes de nuevo en una sola cadena de texto,
-	// usando el salto de línea para preservar los párrafos.
-	cleanedText := strings.Join(relevantLines, "\n")
-
-	// Finalmente, eliminamos cualquier espacio en blanco al principio o al final
-	// que pudiera haber quedado.
-	return strings.TrimSpace(cleanedText)
-}
-
-// formatSrtTime convierte "HH_MM_SS_FFF" a "HH:MM:SS,FFF".
-func formatSrtTime(t string) (string, error) {
-	parts := strings.Split(t, "_")
-	if len(parts) != 4 {
-		return "", fmt.Errorf("formato de tiempo inválido: %s", t)
-	}
-	return fmt.Sprintf("%s:%s:%s,%s", parts[0], parts[1], parts[2], parts[3]), nil
+	return textBatch, false // Devolvemos el lote original si todo falla
 }
 
-// parseFilename extrae los tiempos de inicio y fin del nombre de archivo.
-func parseFilename(filename string) (string, string, error) {
+// parseFilename extrae los tiempos de inicio y fin del nombre de archivo de
+// VideoSubFinder, "H_MM_SS_mmm__H_MM_SS_mmm[_sufijo].ext".
+func parseFilename(filename string) (time.Duration, time.Duration, error) {
 	base := strings.TrimSuffix(filename, filepath.Ext(filename))
 	parts := strings.Split(base, "__")
 	if len(parts) != 2 {
-		return "", "", fmt.Errorf("el nombre de archivo no contiene el separador '__': %s", filename)
+		return 0, 0, fmt.Errorf("el nombre de archivo no contiene el separador '__': %s", filename)
 	}
 
-	startTime, err := formatSrtTime(parts[0])
+	startTime, err := parseVSFTime(parts[0])
 	if err != nil {
-		return "", "", err
+		return 0, 0, err
 	}
 	auxend := strings.Split(parts[1], "_")
 	if len(auxend) > 4 {
 		auxend = auxend[:len(auxend)-1]
 		parts[1] = strings.Join(auxend, "_")
 	}
-	endTime, err := formatSrtTime(parts[1])
+	endTime, err := parseVSFTime(parts[1])
 	if err != nil {
-		return "", "", err
+		return 0, 0, err
+	}
+	if endTime < startTime {
+		return 0, 0, fmt.Errorf("el tiempo de fin es anterior al de inicio: %s", filename)
 	}
 
 	return startTime, endTime, nil
 }
 
 // CreateSrtFromTextFiles lee una carpeta de archivos .txt, los ordena,
-// y construye un archivo .srt.
-func CreateSrtFromTextFiles(textFolder, outputSrtFile string, geminiClient *genai.Client) error {
+// y construye un archivo de subtítulos en el formato de opts (SRT por defecto).
+// Si cache no es nil, los lotes ya corregidos en una ejecución anterior se
+// toman de ahí en lugar de volver a pedirlos a Gemini.
+func CreateSrtFromTextFiles(textFolder, outputSrtFile string, geminiClient *genai.Client, cache CorrectionCache, opts Options) error {
 	log.Println("--- Iniciando construcción de archivo SRT ---")
-	batchSize := 100
 	ctx := context.Background()
 
 	// 1. Leer y ordenar los archivos de texto. La ordenación es crucial.
@@ -132,10 +125,18 @@ func CreateSrtFromTextFiles(textFolder, outputSrtFile string, geminiClient *gena
 	log.Printf("✓ Se encontraron y ordenaron %d archivos de texto.", len(textFilenames))
 
 	// 2. Construir la lista de bloques de subtítulos
+	parseTimes := opts.FilenameParser
+	if parseTimes == nil {
+		parseTimes = parseFilename
+	}
+	cleaner := opts.Cleaner
+	if cleaner == nil {
+		cleaner = DefaultCleaner()
+	}
 	var blocks []SubtitleBlock
-	for i, filename := range textFilenames {
+	for _, filename := range textFilenames {
 		// Parsear el nombre del archivo para los tiempos
-		start, end, err := parseFilename(filename)
+		start, end, err := parseTimes(filename)
 		if err != nil {
 			log.Printf("  [!] ADVERTENCIA: Saltando archivo con nombre inválido '%s': %v", filename, err)
 			continue
@@ -148,66 +149,97 @@ func CreateSrtFromTextFiles(textFolder, outputSrtFile string, geminiClient *gena
 			content = []byte("[ERROR DE LECTURA]")
 		}
 
+		// La numeración se asigna en ProcessBlocks, tras filtrar y fusionar.
 		blocks = append(blocks, SubtitleBlock{
-			Sequence:  i + 1,
-			StartTime: start,
-			EndTime:   end,
-			Text:      cleanOcrText(string(content)),
+			Start: start,
+			End:   end,
+			Text:  cleaner.Clean(string(content), strings.TrimSuffix(filename, filepath.Ext(filename))),
 		})
 	}
-	// Ahora, si tenemos cliente de IA, procesamos los textos en lotes
-	if geminiClient != nil {
-		for i := 0; i < len(blocks); i += batchSize {
-			end := min(i+batchSize, len(blocks))
-
-			// Extraemos el lote de textos originales
-			currentBatchBlocks := blocks[i:end]
-			originalTextBatch := make([]string, len(currentBatchBlocks))
-			for j, block := range currentBatchBlocks {
-				originalTextBatch[j] = block.Text
-			}
 
-			// Procesamos el lote con Gemini
-			correctedTextBatch := processBatch(ctx, geminiClient, originalTextBatch)
+	// 3. Corregir, fusionar y ajustar los tiempos
+	blocks = ProcessBlocks(ctx, blocks, geminiClient, cache, opts)
 
-			// Actualizamos los bloques con los textos corregidos
-			if len(correctedTextBatch) == len(currentBatchBlocks) {
-				for j := range currentBatchBlocks {
-					blocks[i+j].Text = correctedTextBatch[j]
-				}
-			} else {
-				log.Printf("[!] ERROR CRÍTICO: El tamaño del lote devuelto (%d) no coincide con el enviado (%d). Se usarán textos originales para este lote.", len(correctedTextBatch), len(currentBatchBlocks))
-			}
-		}
+	// 4. Escribir el archivo final
+	if err := WriteFile(outputSrtFile, blocks, opts); err != nil {
+		return err
 	}
 
-	// 3. Escribir el archivo .srt final
-	log.Printf("✍️  Escribiendo archivo final: %s", outputSrtFile)
-	file, err := os.Create(outputSrtFile)
-	if err != nil {
-		return fmt.Errorf("no se pudo crear el archivo SRT: %w", err)
+	log.Println("🎉 ¡Archivo de subtítulos creado exitosamente!")
+	return nil
+}
+
+// ProcessBlocks ordena los bloques por inicio y les aplica, en este orden, el
+// desplazamiento y reescalado de tiempos, la corrección con Gemini (si
+// geminiClient no es nil), la fusión de líneas repetidas y el ajuste de tiempos
+// de opts. Al final los renumera desde 1.
+func ProcessBlocks(ctx context.Context, blocks []SubtitleBlock, geminiClient *genai.Client, cache CorrectionCache, opts Options) []SubtitleBlock {
+	// El orden alfabético de los nombres de archivo falla a partir de las 10 horas.
+	SortByStart(blocks)
+	blocks = Retime(blocks, opts.Retime)
+	if geminiClient != nil {
+		correctBlocks(ctx, blocks, geminiClient, cache)
 	}
-	defer file.Close()
+	blocks = MergeDuplicates(blocks, opts.Merge)
+	blocks = NormalizeTiming(blocks, opts.Timing)
+	return Renumber(blocks)
+}
 
-	for _, block := range blocks {
-		// Si el texto está vacío, opcionalmente podemos saltarlo o poner un placeholder.
-		// Aquí lo incluiremos para mantener la secuencia.
-		if block.Text == "" {
-			block.Text = "..."
+// correctBlocks corrige el texto de los bloques con Gemini en lotes. Si cache
+// no es nil, los lotes ya corregidos se toman de ahí.
+func correctBlocks(ctx context.Context, blocks []SubtitleBlock, geminiClient *genai.Client, cache CorrectionCache) {
+	batchSize := 100
+	for i := 0; i < len(blocks); i += batchSize {
+		end := min(i+batchSize, len(blocks))
+
+		// Extraemos el lote de textos originales
+		currentBatchBlocks := blocks[i:end]
+		originalTextBatch := make([]string, len(currentBatchBlocks))
+		for j, block := range currentBatchBlocks {
+			originalTextBatch[j] = block.Text
 		}
 
-		srtEntry := fmt.Sprintf("%d\n%s --> %s\n%s\n\n",
-			block.Sequence,
-			block.StartTime,
-			block.EndTime,
-			block.Text)
+		// Procesamos el lote con Gemini, salvo que ya esté corregido en la caché
+		key := batchKey(geminifix.CorrectionModel, originalTextBatch)
+		correctedTextBatch, cached := []string(nil), false
+		if cache != nil {
+			correctedTextBatch, cached = cache.CorrectedBatch(key)
+		}
+		if cached {
+			log.Printf("  [SKIP] Lote %d-%d ya corregido en una ejecución anterior.", i+1, end)
+		} else {
+			var ok bool
+			correctedTextBatch, ok = processBatch(ctx, geminiClient, originalTextBatch)
+			if ok && cache != nil {
+				if err := cache.RecordBatch(key, correctedTextBatch); err != nil {
+					log.Printf("  [!] ADVERTENCIA: No se pudo guardar el lote en el manifiesto: %v", err)
+				}
+			}
+		}
 
-		if _, err := file.WriteString(srtEntry); err != nil {
-			// Devolvemos el primer error que encontremos al escribir
-			return fmt.Errorf("error al escribir en el archivo SRT: %w", err)
+		// Actualizamos los bloques con los textos corregidos
+		if len(correctedTextBatch) == len(currentBatchBlocks) {
+			for j := range currentBatchBlocks {
+				blocks[i+j].Text = correctedTextBatch[j]
+			}
+		} else {
+			log.Printf("[!] ERROR CRÍTICO: El tamaño del lote devuelto (%d) no coincide con el enviado (%d). Se usarán textos originales para este lote.", len(correctedTextBatch), len(currentBatchBlocks))
 		}
 	}
+}
 
-	log.Println("🎉 ¡Archivo SRT creado exitosamente!")
-	return nil
+// WriteFile crea el archivo de subtítulos en el formato de opts. Los bloques
+// deben pasar Validate; si no, no se crea el archivo.
+func WriteFile(path string, blocks []SubtitleBlock, opts Options) error {
+	if err := Validate(blocks); err != nil {
+		return fmt.Errorf("el subtítulo no es válido: %w", err)
+	}
+	log.Printf("✍️  Escribiendo archivo final: %s", path)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("no se pudo crear el archivo de subtítulos: %w", err)
+	}
+	defer file.Close()
+
+	return WriteSubtitles(file, blocks, opts)
 }
@@ -0,0 +1,85 @@
+// srtbuilder/timestamp.go
+package srtbuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTimeFields valida horas, minutos, segundos y milisegundos ya separados
+// y los convierte en una duración. original se usa solo en los errores.
+func parseTimeFields(original, hours, minutes, seconds, millis string) (time.Duration, error) {
+	fields := []struct {
+		name  string
+		value string
+		limit int
+	}{
+		{"horas", hours, -1},
+		{"minutos", minutes, 60},
+		{"segundos", seconds, 60},
+		{"milisegundos", millis, 1000},
+	}
+
+	var values [4]int
+	for i, f := range fields {
+		if f.value == "" || strings.TrimLeft(f.value, "0123456789") != "" {
+			return 0, fmt.Errorf("formato de tiempo inválido: %s (%s no numéricos)", original, f.name)
+		}
+		n, err := strconv.Atoi(f.value)
+		if err != nil {
+			return 0, fmt.Errorf("formato de tiempo inválido: %s: %v", original, err)
+		}
+		if f.limit > 0 && n >= f.limit {
+			return 0, fmt.Errorf("formato de tiempo inválido: %s (%s fuera de rango: %d)", original, f.name, n)
+		}
+		values[i] = n
+	}
+	if len(millis) != 3 {
+		return 0, fmt.Errorf("formato de tiempo inválido: %s (se esperaban 3 dígitos de milisegundos)", original)
+	}
+
+	return time.Duration(values[0])*time.Hour +
+		time.Duration(values[1])*time.Minute +
+		time.Duration(values[2])*time.Second +
+		time.Duration(values[3])*time.Millisecond, nil
+}
+
+// parseVSFTime convierte un tiempo de VideoSubFinder "H_MM_SS_mmm" en una duración.
+func parseVSFTime(t string) (time.Duration, error) {
+	parts := strings.Split(t, "_")
+	if len(parts) != 4 {
+		return 0, fmt.Errorf("formato de tiempo inválido: %s", t)
+	}
+	return parseTimeFields(t, parts[0], parts[1], parts[2], parts[3])
+}
+
+// splitDuration descompone una duración (negativas cuentan como cero) en
+// horas, minutos, segundos y el resto en la unidad indicada.
+func splitDuration(d, unit time.Duration) (h, m, s, frac int64) {
+	d = max(d, 0)
+	h = int64(d / time.Hour)
+	m = int64(d / time.Minute % 60)
+	s = int64(d / time.Second % 60)
+	frac = int64(d % time.Second / unit)
+	return h, m, s, frac
+}
+
+// formatSRTTime formatea un tiempo como "HH:MM:SS,mmm".
+func formatSRTTime(d time.Duration) string {
+	h, m, s, ms := splitDuration(d, time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+// formatVTTTime formatea un tiempo como "HH:MM:SS.mmm".
+func formatVTTTime(d time.Duration) string {
+	h, m, s, ms := splitDuration(d, time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+// formatASSTime formatea un tiempo como "H:MM:SS.cc", redondeando a centésimas.
+func formatASSTime(d time.Duration) string {
+	h, m, s, cs := splitDuration(d.Round(10*time.Millisecond), 10*time.Millisecond)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", h, m, s, cs)
+}
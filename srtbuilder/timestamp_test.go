@@ -0,0 +1,49 @@
+package srtbuilder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFilename(t *testing.T) {
+	start, end, err := parseFilename("1_02_03_040__1_02_05_999_0001.txt")
+	if err != nil {
+		t.Fatalf("parseFilename: %v", err)
+	}
+	if want := time.Hour + 2*time.Minute + 3*time.Second + 40*time.Millisecond; start != want {
+		t.Errorf("inicio = %v, se esperaba %v", start, want)
+	}
+	if want := time.Hour + 2*time.Minute + 5*time.Second + 999*time.Millisecond; end != want {
+		t.Errorf("fin = %v, se esperaba %v", end, want)
+	}
+
+	for _, name := range []string{
+		"0_00_01_000.txt",                  // sin separador
+		"0_60_01_000__0_00_02_000.txt",     // minutos fuera de rango
+		"0_00_61_000__0_00_62_000.txt",     // segundos fuera de rango
+		"0_00_01_00__0_00_02_000.txt",      // milisegundos con 2 dígitos
+		"0_00_0a_000__0_00_02_000.txt",     // no numérico
+		"0_00_05_000__0_00_02_000.txt",     // fin antes del inicio
+		"0_00_01_000__0_00_02_000_x_y.txt", // sufijo inesperado
+	} {
+		if _, _, err := parseFilename(name); err == nil {
+			t.Errorf("parseFilename(%q) no devolvió error", name)
+		}
+	}
+}
+
+func TestFormatTimes(t *testing.T) {
+	d := 10*time.Hour + 5*time.Minute + 7*time.Second + 996*time.Millisecond
+	if got, want := formatSRTTime(d), "10:05:07,996"; got != want {
+		t.Errorf("formatSRTTime = %q, se esperaba %q", got, want)
+	}
+	if got, want := formatVTTTime(d), "10:05:07.996"; got != want {
+		t.Errorf("formatVTTTime = %q, se esperaba %q", got, want)
+	}
+	if got, want := formatASSTime(d), "10:05:08.00"; got != want {
+		t.Errorf("formatASSTime = %q, se esperaba %q", got, want)
+	}
+	if got, want := formatSRTTime(-time.Second), "00:00:00,000"; got != want {
+		t.Errorf("formatSRTTime(negativo) = %q, se esperaba %q", got, want)
+	}
+}
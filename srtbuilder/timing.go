@@ -0,0 +1,95 @@
+// srtbuilder/timing.go
+package srtbuilder
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TimingOptions controla el ajuste de tiempos de los subtítulos. Cada regla se
+// desactiva dejando su valor a cero.
+type TimingOptions struct {
+	// Enabled activa el ajuste de tiempos.
+	Enabled bool
+	// MinDuration es el tiempo mínimo en pantalla; evita los destellos de
+	// un par de fotogramas.
+	MinDuration time.Duration
+	// MaxDuration es el tiempo máximo en pantalla.
+	MaxDuration time.Duration
+	// CloseGap cierra los huecos menores que este valor alargando el
+	// subtítulo anterior hasta el inicio del siguiente.
+	CloseGap time.Duration
+	// CharsPerSecond es la velocidad de lectura: los subtítulos que duran
+	// menos de lo necesario para leerlos se alargan.
+	CharsPerSecond float64
+}
+
+// DefaultTimingOptions son valores habituales en subtítulos profesionales. El
+// ajuste está desactivado: cambia los tiempos leídos de las imágenes, así que
+// hay que pedirlo.
+var DefaultTimingOptions = TimingOptions{
+	MinDuration:    800 * time.Millisecond,
+	MaxDuration:    7 * time.Second,
+	CloseGap:       200 * time.Millisecond,
+	CharsPerSecond: 20,
+}
+
+// readableLength cuenta los caracteres que hay que leer: sin etiquetas de
+// formato ni saltos de línea.
+func readableLength(text string) int {
+	text = strings.NewReplacer("<i>", "", "</i>", "", "<b>", "", "</b>", "", "\n", "").Replace(text)
+	return utf8.RuneCountInString(strings.TrimSpace(text))
+}
+
+// NormalizeTiming ajusta los tiempos de los bloques, que deben estar ordenados
+// por inicio. Para cada bloque, en este orden:
+//  1. lo alarga hasta la duración de lectura (CharsPerSecond) y hasta MinDuration;
+//  2. lo recorta a MaxDuration;
+//  3. si se solapa con el siguiente, adelanta su fin al inicio del siguiente;
+//  4. si queda un hueco menor que CloseGap, lo alarga hasta el siguiente,
+//     salvo que así supere MaxDuration.
+//
+// Los inicios nunca se mueven.
+func NormalizeTiming(blocks []SubtitleBlock, opts TimingOptions) []SubtitleBlock {
+	if !opts.Enabled {
+		return blocks
+	}
+
+	adjusted := 0
+	for i := range blocks {
+		start, end := blocks[i].Start, blocks[i].End
+
+		if opts.CharsPerSecond > 0 {
+			reading := time.Duration(float64(readableLength(blocks[i].Text)) / opts.CharsPerSecond * float64(time.Second))
+			end = max(end, start+reading)
+		}
+		if opts.MinDuration > 0 {
+			end = max(end, start+opts.MinDuration)
+		}
+		if opts.MaxDuration > 0 {
+			end = min(end, start+opts.MaxDuration)
+		}
+
+		if i+1 < len(blocks) {
+			if next := blocks[i+1].Start; next > start {
+				if end > next {
+					end = next
+				} else if gap := next - end; gap > 0 && gap < opts.CloseGap && (opts.MaxDuration <= 0 || next-start <= opts.MaxDuration) {
+					end = next
+				}
+			}
+		}
+
+		if end != blocks[i].End {
+			blocks[i].End = end
+			adjusted++
+		}
+	}
+
+	if adjusted > 0 {
+		log.Printf("✓ Se ajustaron los tiempos de %d subtítulos.", adjusted)
+	}
+	return blocks
+}
@@ -0,0 +1,52 @@
+package srtbuilder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeTiming(t *testing.T) {
+	blocks := []SubtitleBlock{
+		{1, at("0:00:01,000"), at("0:00:01,040"), "Sí"},                       // destello: duración mínima
+		{2, at("0:00:02,000"), at("0:00:02,500"), "Una frase bastante larga"}, // velocidad de lectura, hasta el siguiente
+		{3, at("0:00:03,000"), at("0:00:05,000"), "Se solapa"},                // solapa con el 4
+		{4, at("0:00:04,500"), at("0:00:30,000"), "Demasiado larga"},          // duración máxima
+		{5, at("0:00:12,000"), at("0:00:12,900"), "Casi pegada"},              // hueco pequeño con el 6
+		{6, at("0:00:13,000"), at("0:00:14,000"), "Fin"},
+	}
+
+	opts := DefaultTimingOptions
+	opts.Enabled = true
+	got := NormalizeTiming(blocks, opts)
+	want := []time.Duration{
+		at("0:00:01,800"),
+		at("0:00:03,000"),
+		at("0:00:04,500"),
+		at("0:00:11,500"),
+		at("0:00:13,000"),
+		at("0:00:14,000"),
+	}
+	for i, end := range want {
+		if got[i].End != end {
+			t.Errorf("bloque %d: fin = %s, se esperaba %s", got[i].Sequence, got[i].End, end)
+		}
+	}
+
+	off := []SubtitleBlock{{1, at("0:00:01,000"), at("0:00:01,040"), "Sí"}}
+	if got := NormalizeTiming(off, TimingOptions{Enabled: true, MaxDuration: time.Second}); got[0].End != at("0:00:01,040") {
+		t.Errorf("una regla desactivada cambió el fin a %s", got[0].End)
+	}
+
+	// Cerrar el hueco no puede saltarse la duración máxima.
+	capped := []SubtitleBlock{
+		{1, at("0:00:00,000"), at("0:00:10,000"), "Larga"},
+		{2, at("0:00:07,100"), at("0:00:08,000"), "Siguiente"},
+	}
+	if got := NormalizeTiming(capped, opts); got[0].End != at("0:00:07,000") {
+		t.Errorf("fin con hueco tras la duración máxima = %s, se esperaba 0:00:07", got[0].End)
+	}
+
+	if got := NormalizeTiming(off, DefaultTimingOptions); got[0].End != at("0:00:01,040") {
+		t.Errorf("el ajuste de tiempos debe estar desactivado por defecto, cambió el fin a %s", got[0].End)
+	}
+}
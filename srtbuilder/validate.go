@@ -0,0 +1,40 @@
+// srtbuilder/validate.go
+package srtbuilder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortByStart ordena los bloques por inicio, conservando el orden original
+// de los que empiezan a la vez.
+func SortByStart(blocks []SubtitleBlock) {
+	sort.SliceStable(blocks, func(a, b int) bool { return blocks[a].Start < blocks[b].Start })
+}
+
+// Renumber asigna números de secuencia consecutivos desde 1. Debe llamarse
+// después de cualquier paso que quite o fusione bloques.
+func Renumber(blocks []SubtitleBlock) []SubtitleBlock {
+	for i := range blocks {
+		blocks[i].Sequence = i + 1
+	}
+	return blocks
+}
+
+// Validate comprueba lo que algunos reproductores exigen a un subtítulo:
+// numeración consecutiva desde 1, inicios en orden no decreciente y ningún
+// bloque que termine antes de empezar.
+func Validate(blocks []SubtitleBlock) error {
+	for i, block := range blocks {
+		if block.Sequence != i+1 {
+			return fmt.Errorf("numeración incorrecta: el bloque %d tiene el número %d", i+1, block.Sequence)
+		}
+		if block.End < block.Start {
+			return fmt.Errorf("el bloque %d termina (%s) antes de empezar (%s)", block.Sequence, formatSRTTime(block.End), formatSRTTime(block.Start))
+		}
+		if i > 0 && block.Start < blocks[i-1].Start {
+			return fmt.Errorf("el bloque %d empieza (%s) antes que el anterior (%s)", block.Sequence, formatSRTTime(block.Start), formatSRTTime(blocks[i-1].Start))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package srtbuilder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateSrtRenumbersSkippedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, text := range map[string]string{
+		"0_00_01_000__0_00_02_000_0001.txt": "\n\nUno",
+		"nombre_invalido.txt":               "\n\nIgnorado",
+		"0_00_05_000__0_00_06_000_0003.txt": "\n\nDos",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(dir, "salida.srt")
+	if err := CreateSrtFromTextFiles(dir, out, nil, nil, Options{}); err != nil {
+		t.Fatalf("CreateSrtFromTextFiles: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,000\nUno\n\n2\n00:00:05,000 --> 00:00:06,000\nDos\n\n"
+	if string(got) != want {
+		t.Errorf("SRT = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ok := []SubtitleBlock{
+		{1, time.Second, 2 * time.Second, "a"},
+		{2, time.Second, 3 * time.Second, "b"},
+	}
+	if err := Validate(ok); err != nil {
+		t.Errorf("Validate(válido) = %v", err)
+	}
+
+	for name, blocks := range map[string][]SubtitleBlock{
+		"hueco en la numeración": {{1, 0, time.Second, "a"}, {3, time.Second, 2 * time.Second, "b"}},
+		"no empieza en 1":        {{0, 0, time.Second, "a"}},
+		"inicio decreciente":     {{1, 2 * time.Second, 3 * time.Second, "a"}, {2, time.Second, 4 * time.Second, "b"}},
+		"fin antes del inicio":   {{1, 2 * time.Second, time.Second, "a"}},
+	} {
+		if err := Validate(blocks); err == nil {
+			t.Errorf("%s: Validate no devolvió error", name)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// srtbuilder/writers.go
+package srtbuilder
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formatos de salida soportados.
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// Formats es la lista de formatos aceptados por Options.Format.
+var Formats = []string{FormatSRT, FormatVTT, FormatASS}
+
+// Options controla cómo se escribe el archivo de subtítulos.
+type Options struct {
+	// Format es uno de Formats. Vacío equivale a FormatSRT.
+	Format string
+	// VTTCueSettings se añade tras los tiempos de cada cue en WebVTT,
+	// por ejemplo "line:85% align:center".
+	VTTCueSettings string
+	// ASSStyle es el estilo "Default" del script ASS. Con el valor cero se
+	// usa DefaultASSStyle.
+	ASSStyle ASSStyle
+	// ASSTemplate es la ruta de un archivo .ass cuya cabecera ([Script Info],
+	// [V4+ Styles]) se copia tal cual; los diálogos usan su primer estilo.
+	ASSTemplate string
+	// FilenameParser obtiene los tiempos de los nombres de los archivos de
+	// texto. Si es nil se usa el formato de VideoSubFinder.
+	FilenameParser FilenameParser
+	// Cleaner limpia el texto de OCR de cada archivo. Si es nil se usa
+	// DefaultCleaner.
+	Cleaner *Cleaner
+	// Retime desplaza y reescala los tiempos antes de cualquier otro ajuste.
+	Retime RetimeOptions
+	// Merge controla la fusión de subtítulos consecutivos repetidos antes de
+	// escribir el archivo.
+	Merge MergeOptions
+	// Timing controla el ajuste de tiempos, que se aplica tras la fusión.
+	Timing TimingOptions
+}
+
+// Extension devuelve la extensión de archivo (con punto) de un formato.
+func Extension(format string) string {
+	if format == "" {
+		format = FormatSRT
+	}
+	return "." + format
+}
+
+// WriteSubtitles escribe los bloques en el formato indicado en opts.
+func WriteSubtitles(w io.Writer, blocks []SubtitleBlock, opts Options) error {
+	switch opts.Format {
+	case FormatSRT, "":
+		return writeSRT(w, blocks)
+	case FormatVTT:
+		return writeVTT(w, blocks, opts.VTTCueSettings)
+	case FormatASS:
+		style := opts.ASSStyle
+		if style == (ASSStyle{}) {
+			style = DefaultASSStyle
+		}
+		return writeASS(w, blocks, style, opts.ASSTemplate)
+	default:
+		return fmt.Errorf("formato de subtítulos desconocido: %q (soportados: %s)", opts.Format, strings.Join(Formats, ", "))
+	}
+}
+
+// blockText devuelve el texto a escribir para un bloque. Si está vacío ponemos
+// un placeholder para mantener la secuencia.
+func blockText(block SubtitleBlock) string {
+	if block.Text == "" {
+		return "..."
+	}
+	return block.Text
+}
+
+// writeSRT escribe los bloques en formato SRT.
+func writeSRT(w io.Writer, blocks []SubtitleBlock) error {
+	for _, block := range blocks {
+		srtEntry := fmt.Sprintf("%d\n%s --> %s\n%s\n\n",
+			block.Sequence,
+			formatSRTTime(block.Start),
+			formatSRTTime(block.End),
+			blockText(block))
+
+		if _, err := io.WriteString(w, srtEntry); err != nil {
+			// Devolvemos el primer error que encontremos al escribir
+			return fmt.Errorf("error al escribir en el archivo SRT: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeVTT escribe los bloques en formato WebVTT. Los identificadores de cue
+// son el número de secuencia, igual que en SRT.
+func writeVTT(w io.Writer, blocks []SubtitleBlock, cueSettings string) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return fmt.Errorf("error al escribir en el archivo VTT: %w", err)
+	}
+
+	settings := ""
+	if cueSettings = strings.TrimSpace(cueSettings); cueSettings != "" {
+		settings = " " + cueSettings
+	}
+	for _, block := range blocks {
+		vttEntry := fmt.Sprintf("%d\n%s --> %s%s\n%s\n\n",
+			block.Sequence,
+			formatVTTTime(block.Start),
+			formatVTTTime(block.End),
+			settings,
+			blockText(block))
+
+		if _, err := io.WriteString(w, vttEntry); err != nil {
+			return fmt.Errorf("error al escribir en el archivo VTT: %w", err)
+		}
+	}
+	return nil
+}
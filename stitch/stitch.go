@@ -0,0 +1,151 @@
+// Package stitch apila varias tiras de subtítulo en una sola imagen, separadas
+// por marcadores numerados, para reconocerlas con una única petición de OCR y
+// repartir después el texto entre las imágenes originales.
+package stitch
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// glyphs es una fuente de mapa de bits de 5x7 con los caracteres de los
+// marcadores. Cada cadena es una fila; '#' es un píxel encendido.
+var glyphs = map[rune][7]string{
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+	'=': {"     ", "     ", "#####", "     ", "#####", "     ", "     "},
+	' ': {"     ", "     ", "     ", "     ", "     ", "     ", "     "},
+}
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	// glyphScale amplía la fuente para que el OCR la lea sin problemas.
+	glyphScale = 6
+	// markerMargin es el espacio en blanco por encima y por debajo del
+	// marcador, para que el OCR no lo junte con el texto de las tiras.
+	markerMargin = 4 * glyphScale
+)
+
+// Marker devuelve el texto del marcador que precede a la tira i (desde 1).
+func Marker(i int) string {
+	return fmt.Sprintf("=== %d ===", i)
+}
+
+// drawText dibuja el texto con la fuente de mapa de bits en negro.
+func drawText(dst draw.Image, at image.Point, text string) {
+	x := at.X
+	for _, r := range text {
+		glyph := glyphs[r]
+		for row, line := range glyph {
+			for col, px := range line {
+				if px != '#' {
+					continue
+				}
+				cell := image.Rect(x+col*glyphScale, at.Y+row*glyphScale, x+(col+1)*glyphScale, at.Y+(row+1)*glyphScale)
+				draw.Draw(dst, cell, image.Black, image.Point{}, draw.Src)
+			}
+		}
+		x += (glyphWidth + 1) * glyphScale
+	}
+}
+
+// textWidth devuelve el ancho en píxeles del texto dibujado con drawText.
+func textWidth(text string) int {
+	return len(text) * (glyphWidth + 1) * glyphScale
+}
+
+// Images apila las imágenes de arriba abajo sobre fondo blanco, cada una
+// precedida por Marker(i).
+func Images(imgs []image.Image) *image.NRGBA {
+	markerHeight := glyphHeight*glyphScale + 2*markerMargin
+	width, height := 0, 0
+	for i, img := range imgs {
+		width = max(width, img.Bounds().Dx(), textWidth(Marker(i+1))+2*markerMargin)
+		height += markerHeight + img.Bounds().Dy()
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	y := 0
+	for i, img := range imgs {
+		drawText(dst, image.Pt(markerMargin, y+markerMargin), Marker(i+1))
+		y += markerHeight
+		b := img.Bounds()
+		draw.Draw(dst, image.Rect(0, y, b.Dx(), y+b.Dy()), img, b.Min, draw.Over)
+		y += b.Dy()
+	}
+	return dst
+}
+
+// markerRe reconoce una línea de marcador tal como la devuelve el OCR, que a
+// veces lee los signos "=" como de ancho completo o como guiones.
+var markerRe = regexp.MustCompile(`^[=＝\-－]{2,}\s*([0-9０-９]+)\s*[=＝\-－]{2,}$`)
+
+// tagRe quita las etiquetas de formato que puede haber alrededor del marcador.
+var tagRe = regexp.MustCompile(`</?[ib]>`)
+
+// markerIndex devuelve el número del marcador de la línea, o 0 si no lo es.
+func markerIndex(line string) int {
+	line = strings.TrimSpace(tagRe.ReplaceAllString(line, ""))
+	m := markerRe.FindStringSubmatch(line)
+	if m == nil {
+		return 0
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return r - '０' + '0'
+		}
+		return r
+	}, m[1])
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Split reparte el texto reconocido en la imagen de Images entre sus n tiras.
+// El texto anterior al primer marcador (p. ej. el título del documento) se
+// descarta. Devuelve un error si no aparecen exactamente los marcadores 1 a n
+// en orden, y en ese caso hay que reconocer las imágenes una a una.
+func Split(text string, n int) ([]string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var parts []string
+	var current []string
+	seen := 0
+	for _, line := range strings.Split(text, "\n") {
+		if idx := markerIndex(line); idx > 0 {
+			if idx != seen+1 {
+				return nil, fmt.Errorf("se esperaba el marcador %d y se leyó el %d", seen+1, idx)
+			}
+			if seen > 0 {
+				parts = append(parts, strings.TrimSpace(strings.Join(current, "\n")))
+			}
+			seen = idx
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	if seen > 0 {
+		parts = append(parts, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+	if len(parts) != n {
+		return nil, fmt.Errorf("se leyeron %d marcadores de %d", len(parts), n)
+	}
+	return parts, nil
+}
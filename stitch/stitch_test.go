@@ -0,0 +1,68 @@
+package stitch
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	text := "stitch_0_00_01_000\n" +
+		Marker(1) + "\nHola\n\n" +
+		"<b>===</b> 2 ===\r\n¿Qué tal?\nBien\n" +
+		"＝＝＝ ３ ＝＝＝\n\n" +
+		"--- 4 ---\n♪ Fin ♪\n"
+	got, err := Split(text, 4)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	want := []string{"Hola", "¿Qué tal?\nBien", "", "♪ Fin ♪"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestSplitMismatch(t *testing.T) {
+	cases := map[string]string{
+		"falta uno":      Marker(1) + "\nA\n" + Marker(2) + "\nB\n",
+		"mal leído":      Marker(1) + "\nA\n" + Marker(7) + "\nB\n" + Marker(3) + "\nC\n",
+		"sin marcadores": "A\nB\nC\n",
+	}
+	for name, text := range cases {
+		if _, err := Split(text, 3); err == nil {
+			t.Errorf("%s: se esperaba un error", name)
+		}
+	}
+}
+
+func TestImages(t *testing.T) {
+	strip := func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := range img.Pix {
+			img.Pix[i] = 0x80
+			if i%4 == 3 {
+				img.Pix[i] = 0xff
+			}
+		}
+		return img
+	}
+	out := Images([]image.Image{strip(400, 50), strip(600, 30)})
+
+	markerHeight := glyphHeight*glyphScale + 2*markerMargin
+	if got, want := out.Bounds(), image.Rect(0, 0, 600, 2*markerHeight+80); got != want {
+		t.Fatalf("tamaño = %v, se esperaba %v", got, want)
+	}
+	// La primera tira empieza justo después de su marcador, y a su derecha
+	// el fondo es blanco.
+	if c := out.NRGBAAt(0, markerHeight); c.R != 0x80 {
+		t.Errorf("primera tira = %v, se esperaba gris", c)
+	}
+	if c := out.NRGBAAt(500, markerHeight); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("fondo = %v, se esperaba blanco", c)
+	}
+	// El primer "=" del marcador está dibujado en negro.
+	if c := out.NRGBAAt(markerMargin, markerMargin+2*glyphScale); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Errorf("marcador = %v, se esperaba negro", c)
+	}
+}
@@ -0,0 +1,157 @@
+// stitching.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/yoshi70001/googleDocsOCR/manifest"
+	"github.com/yoshi70001/googleDocsOCR/ocr"
+	"github.com/yoshi70001/googleDocsOCR/stitch"
+)
+
+// ocrStats cuenta las imágenes reconocidas y las peticiones al motor que
+// costaron, para mostrar el ahorro del modo -stitch.
+type ocrStats struct {
+	images   atomic.Int64
+	requests atomic.Int64
+}
+
+// log muestra el resumen si alguna petición reconoció más de una imagen.
+func (s *ocrStats) log() {
+	images, requests := s.images.Load(), s.requests.Load()
+	if images > requests {
+		log.Printf("✓ Se reconocieron %d imágenes con %d peticiones de OCR (%d menos).", images, requests, images-requests)
+	}
+}
+
+// groupJobs reparte las imágenes en grupos de size consecutivas. Con size
+// menor que 2 cada imagen va sola.
+func groupJobs(jobs []*imageJob, size int) [][]*imageJob {
+	size = max(size, 1)
+	var groups [][]*imageJob
+	for start := 0; start < len(jobs); start += size {
+		groups = append(groups, jobs[start:min(start+size, len(jobs))])
+	}
+	return groups
+}
+
+// ocrGroup reconoce un grupo de imágenes y devuelve los errores por nombre de
+// imagen. Los grupos de varias imágenes se apilan en una sola petición; si el
+// texto no se puede repartir entre ellas, se reconocen una a una.
+func ocrGroup(ctx context.Context, engine ocr.Engine, m *manifest.Manifest, prep *imagePrep, group []*imageJob, stats *ocrStats) map[string]error {
+	errs := make(map[string]error)
+	single := func(job *imageJob) {
+		stats.images.Add(1)
+		stats.requests.Add(1)
+		if err := ocrImage(ctx, engine, m, prep, job); err != nil {
+			errs[job.filename] = err
+		}
+	}
+	if len(group) == 1 {
+		single(group[0])
+		return errs
+	}
+
+	// Las imágenes que no se pueden preparar fallan por separado y no
+	// impiden apilar el resto.
+	var jobs []*imageJob
+	var imgs []image.Image
+	for _, job := range group {
+		job.record(m, manifest.StatusPending, nil, nil)
+		img, err := loadForStitch(prep, job.imagePath)
+		if err != nil {
+			job.finish(m, nil, err)
+			errs[job.filename] = err
+			continue
+		}
+		jobs = append(jobs, job)
+		imgs = append(imgs, img)
+	}
+	if len(jobs) < 2 {
+		for _, job := range jobs {
+			single(job)
+		}
+		return errs
+	}
+
+	texts, result, err := recognizeStitched(ctx, engine, m, jobs, imgs, stats)
+	if err != nil {
+		log.Printf("[!] ADVERTENCIA: No se pudo usar la imagen apilada de '%s' a '%s' (%v). Se reconocerán una a una.", jobs[0].filename, jobs[len(jobs)-1].filename, err)
+		for _, job := range jobs {
+			single(job)
+		}
+		return errs
+	}
+
+	stats.images.Add(int64(len(jobs)))
+	for i, job := range jobs {
+		if err := os.WriteFile(job.textPath, []byte(texts[i]), 0644); err != nil {
+			err = fmt.Errorf("no se pudo guardar el archivo de texto: %w", err)
+			job.finish(m, nil, err)
+			errs[job.filename] = err
+			continue
+		}
+		metadata := maps.Clone(result.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata["stitch_first"] = jobs[0].filename
+		metadata["stitch_index"] = strconv.Itoa(i + 1)
+		job.finish(m, &ocr.Result{Text: texts[i], Metadata: metadata}, nil)
+	}
+	log.Printf("[✓] %d imágenes reconocidas en una sola petición (de '%s' a '%s').", len(jobs), jobs[0].filename, jobs[len(jobs)-1].filename)
+	return errs
+}
+
+// loadForStitch preprocesa la imagen si hace falta y la decodifica.
+func loadForStitch(prep *imagePrep, imagePath string) (image.Image, error) {
+	path, err := prep.prepare(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir la imagen local %s: %w", path, err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo decodificar la imagen %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// recognizeStitched apila las imágenes, las reconoce con una sola petición y
+// reparte el texto entre ellas. La petición cuenta en stats aunque el texto
+// no se pueda repartir.
+func recognizeStitched(ctx context.Context, engine ocr.Engine, m *manifest.Manifest, jobs []*imageJob, imgs []image.Image, stats *ocrStats) ([]string, *ocr.Result, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, stitch.Images(imgs)); err != nil {
+		return nil, nil, fmt.Errorf("no se pudo codificar la imagen apilada: %w", err)
+	}
+
+	first := jobs[0].filename
+	name := "stitch_" + strings.TrimSuffix(first, filepath.Ext(first)) + ".png"
+	log.Printf("[+] Iniciando procesamiento de %d imágenes apiladas desde: %s (motor: %s)", len(jobs), first, engine.Name())
+	stats.requests.Add(1)
+	result, err := engine.Recognize(withRemoteRecorder(ctx, m, jobs...), buf.Bytes(), name)
+	if err != nil {
+		return nil, nil, err
+	}
+	texts, err := stitch.Split(result.Text, len(jobs))
+	if err != nil {
+		return nil, nil, err
+	}
+	return texts, result, nil
+}
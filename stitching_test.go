@@ -0,0 +1,136 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yoshi70001/googleDocsOCR/gdrive"
+	"github.com/yoshi70001/googleDocsOCR/gdrive/drivefake"
+	"github.com/yoshi70001/googleDocsOCR/manifest"
+)
+
+// stitchFixture prepara un motor de Drive contra el servidor falso, un
+// manifiesto y un trabajo por cada nombre de imagen. Los nombres que empiezan
+// por "rota" son archivos que no se pueden decodificar.
+func stitchFixture(t *testing.T, ocrFunc drivefake.OCRFunc, names ...string) (*drivefake.Server, *gdrive.Engine, *manifest.Manifest, []*imageJob) {
+	t.Helper()
+	fake := drivefake.NewServer(ocrFunc)
+	t.Cleanup(fake.Close)
+	srv, err := gdrive.NewServiceForEndpoint(t.Context(), fake.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder := fake.AddFile(drivefake.File{Name: "Temp_OCR_Go", MimeType: "application/vnd.google-apps.folder"})
+
+	dir := t.TempDir()
+	m, err := manifest.Load(filepath.Join(dir, manifest.DefaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jobs []*imageJob
+	for _, name := range names {
+		job := &imageJob{filename: name, imagePath: filepath.Join(dir, name), textPath: filepath.Join(dir, name+".txt")}
+		if strings.HasPrefix(name, "rota") {
+			if err := os.WriteFile(job.imagePath, []byte("no es una imagen"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			f, err := os.Create(job.imagePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := png.Encode(f, image.NewGray(image.Rect(0, 0, 320, 40))); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+		}
+		jobs = append(jobs, job)
+	}
+	return fake, gdrive.NewEngine(srv, folder), m, jobs
+}
+
+// checkEntry comprueba el estado de una imagen en el manifiesto y el valor de
+// stitch_first en sus metadatos ("" si no debe estar).
+func checkEntry(t *testing.T, m *manifest.Manifest, name, status, stitchFirst string) {
+	t.Helper()
+	entry, ok := m.Image(name)
+	if !ok {
+		t.Errorf("%s no está en el manifiesto", name)
+		return
+	}
+	if entry.Status != status || entry.Metadata["stitch_first"] != stitchFirst {
+		t.Errorf("entrada de %s = %+v, se esperaba %s con stitch_first=%q", name, entry, status, stitchFirst)
+	}
+	if status == manifest.StatusDone && entry.Metadata[gdrive.MetadataDocID] == "" {
+		t.Errorf("entrada de %s sin %s", name, gdrive.MetadataDocID)
+	}
+}
+
+func TestOCRGroupFallsBackWhenSplitFails(t *testing.T) {
+	// El "OCR" de la imagen apilada solo lee un marcador de tres.
+	fake, engine, m, jobs := stitchFixture(t, func(name string, image []byte) string {
+		if strings.HasPrefix(name, "stitch_") {
+			return "=== 1 ===\nTodo junto"
+		}
+		return "Texto de " + name
+	}, "a.png", "b.png", "c.png")
+	var stats ocrStats
+
+	errs := ocrGroup(t.Context(), engine, m, nil, jobs, &stats)
+
+	if len(errs) != 0 {
+		t.Errorf("errores = %v, no se esperaba ninguno", errs)
+	}
+	if got := fake.Calls("create"); got != 4 {
+		t.Errorf("create llamado %d veces, se esperaban 4 (la apilada y una por imagen)", got)
+	}
+	for _, job := range jobs {
+		text, err := os.ReadFile(job.textPath)
+		if err != nil {
+			t.Errorf("no se escribió el texto de %s: %v", job.filename, err)
+		} else if want := "Texto de " + strings.TrimSuffix(job.filename, ".png"); !strings.Contains(string(text), want) {
+			t.Errorf("texto de %s = %q, se esperaba %q", job.filename, text, want)
+		}
+		checkEntry(t, m, job.filename, manifest.StatusDone, "")
+	}
+	if images, requests := stats.images.Load(), stats.requests.Load(); images != 3 || requests != 4 {
+		t.Errorf("estadísticas = %d imágenes y %d peticiones, se esperaban 3 y 4", images, requests)
+	}
+}
+
+func TestOCRGroupStitchesAroundBrokenImages(t *testing.T) {
+	fake, engine, m, jobs := stitchFixture(t, func(name string, image []byte) string {
+		return "Título\n=== 1 ===\nHola\n=== 2 ===\nAdiós"
+	}, "a.png", "rota.png", "b.png")
+	var stats ocrStats
+
+	errs := ocrGroup(t.Context(), engine, m, nil, jobs, &stats)
+
+	if len(errs) != 1 || errs["rota.png"] == nil {
+		t.Errorf("errores = %v, se esperaba solo el de rota.png", errs)
+	}
+	if got := fake.Calls("create"); got != 1 {
+		t.Errorf("create llamado %d veces, se esperaba 1", got)
+	}
+	for name, want := range map[string]string{"a.png": "Hola", "b.png": "Adiós"} {
+		text, err := os.ReadFile(filepath.Join(filepath.Dir(jobs[0].textPath), name+".txt"))
+		if err != nil || string(text) != want {
+			t.Errorf("texto de %s = %q (%v), se esperaba %q", name, text, err, want)
+		}
+		checkEntry(t, m, name, manifest.StatusDone, "a.png")
+	}
+	if entry, _ := m.Image("b.png"); entry.Metadata["stitch_index"] != "2" {
+		t.Errorf("stitch_index de b.png = %q, se esperaba 2", entry.Metadata["stitch_index"])
+	}
+	checkEntry(t, m, "rota.png", manifest.StatusFailed, "")
+	if _, err := os.Stat(jobs[1].textPath); !os.IsNotExist(err) {
+		t.Errorf("no debería haberse escrito el texto de rota.png (Stat: %v)", err)
+	}
+	if images, requests := stats.images.Load(), stats.requests.Load(); images != 2 || requests != 1 {
+		t.Errorf("estadísticas = %d imágenes y %d peticiones, se esperaban 2 y 1", images, requests)
+	}
+}
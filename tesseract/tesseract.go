@@ -0,0 +1,76 @@
+// tesseract/tesseract.go
+package tesseract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/yoshi70001/googleDocsOCR/ocr"
+)
+
+// EngineName es el nombre con el que se selecciona este motor en el flag -engine.
+const EngineName = "tesseract"
+
+// defaultPageSegMode le indica a Tesseract que la imagen es un único bloque de
+// texto uniforme, que es lo que suele ser una franja de subtítulos.
+const defaultPageSegMode = 6
+
+// Engine implementa ocr.Engine ejecutando un binario de Tesseract instalado
+// localmente, sin ninguna conexión de red.
+type Engine struct {
+	binary      string
+	languages   string
+	pageSegMode int
+}
+
+// NewEngine crea un motor que usa el binario indicado (buscado en el PATH si no
+// es una ruta) y los paquetes de idioma dados en formato Tesseract, p. ej. "jpn+spa".
+func NewEngine(binary, languages string) (*Engine, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("no se encontró el ejecutable de tesseract '%s': %w", binary, err)
+	}
+	if languages == "" {
+		return nil, fmt.Errorf("no se especificó ningún idioma para tesseract")
+	}
+	return &Engine{binary: path, languages: languages, pageSegMode: defaultPageSegMode}, nil
+}
+
+// Name devuelve el identificador del motor.
+func (e *Engine) Name() string {
+	return EngineName
+}
+
+// AcceptsFormat indica si tesseract lee imágenes en ese formato. GIF y WebP
+// dependen de cómo se compiló leptonica, así que se convierten siempre.
+func (e *Engine) AcceptsFormat(format string) bool {
+	return format == "png" || format == "jpeg" || format == "tiff" || format == "bmp"
+}
+
+// Recognize pasa la imagen a Tesseract por stdin y lee el texto de stdout.
+func (e *Engine) Recognize(ctx context.Context, image []byte, filename string) (*ocr.Result, error) {
+	log.Printf("    - Ejecutando tesseract (idiomas: %s)...", e.languages)
+	cmd := exec.CommandContext(ctx, e.binary, "stdin", "stdout",
+		"-l", e.languages,
+		"--psm", strconv.Itoa(e.pageSegMode))
+	cmd.Stdin = bytes.NewReader(image)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tesseract falló para %s: %w: %s", filename, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return &ocr.Result{
+		Text: strings.TrimSpace(stdout.String()),
+		Metadata: map[string]string{
+			"languages": e.languages,
+		},
+	}, nil
+}
@@ -0,0 +1,94 @@
+package tesseract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeTesseract crea un script que guarda sus argumentos y su stdin en dir y
+// termina con el script dado. Devuelve la ruta del script.
+func fakeTesseract(t *testing.T, dir, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("el tesseract falso es un script de shell")
+	}
+	script := "#!/bin/sh\n" +
+		"printf '%s\\n' \"$@\" > \"" + filepath.Join(dir, "args") + "\"\n" +
+		"cat > \"" + filepath.Join(dir, "stdin") + "\"\n" +
+		body
+	path := filepath.Join(dir, "tesseract")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRecognize(t *testing.T) {
+	dir := t.TempDir()
+	bin := fakeTesseract(t, dir, "printf '  こんにちは\\nHola  \\n\\n'\n")
+	engine, err := NewEngine(bin, "jpn+spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := []byte("\x89PNG datos de la imagen")
+	result, err := engine.Recognize(context.Background(), image, "001.png")
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+	if want := "こんにちは\nHola"; result.Text != want {
+		t.Errorf("texto = %q, se esperaba %q", result.Text, want)
+	}
+	if result.Metadata["languages"] != "jpn+spa" {
+		t.Errorf("metadatos = %v, se esperaba languages=jpn+spa", result.Metadata)
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(args), "\n"), "\n")
+	if want := []string{"stdin", "stdout", "-l", "jpn+spa", "--psm", "6"}; !slices.Equal(got, want) {
+		t.Errorf("argumentos = %q, se esperaba %q", got, want)
+	}
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stdin) != string(image) {
+		t.Errorf("stdin = %q, se esperaba la imagen %q", stdin, image)
+	}
+}
+
+func TestRecognizeFailure(t *testing.T) {
+	bin := fakeTesseract(t, t.TempDir(), "echo 'Error opening data file jpn.traineddata' >&2\nexit 1\n")
+	engine, err := NewEngine(bin, "jpn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = engine.Recognize(context.Background(), []byte("imagen"), "001.png")
+	if err == nil {
+		t.Fatal("se esperaba un error con una salida distinta de cero")
+	}
+	for _, want := range []string{"001.png", "exit status 1", "Error opening data file jpn.traineddata"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("el error %q no contiene %q", err, want)
+		}
+	}
+}
+
+func TestNewEngineErrors(t *testing.T) {
+	if _, err := NewEngine(filepath.Join(t.TempDir(), "no-existe"), "jpn"); err == nil {
+		t.Error("se esperaba un error con un binario inexistente")
+	}
+	bin := fakeTesseract(t, t.TempDir(), "")
+	if _, err := NewEngine(bin, ""); err == nil {
+		t.Error("se esperaba un error sin idiomas")
+	}
+}